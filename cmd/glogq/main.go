@@ -0,0 +1,159 @@
+// glogq 查询glog日志文件，包括轮转和压缩的备份文件
+//
+//	glogq -since "2024-01-02 15:04:05" -level warn -field user=admin -msg "timeout" app.log
+//	glogq -f -output json app.log
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+type fieldFlags map[string]string
+
+func (f fieldFlags) String() string {
+	var s []string
+	for k, v := range f {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f fieldFlags) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("field must be key=value: %q", value)
+	}
+	f[k] = v
+	return nil
+}
+
+func main() {
+	var (
+		since  = flag.String("since", "", "start time, \"2006-01-02 15:04:05\", RFC3339 or a duration like 1h meaning ago")
+		until  = flag.String("until", "", "end time, same format as -since")
+		level  = flag.String("level", "", "minimum level: debug, info, warn, error, fatal, panic")
+		msg    = flag.String("msg", "", "message regular expression")
+		output = flag.String("output", "text", "output format: text or json")
+		follow = flag.Bool("f", false, "follow the log file, surviving rotation")
+		layout = flag.String("time-format", "", "time layout of JSON logs, same as JSONFormatter.TimestampFormat")
+		fields = fieldFlags{}
+	)
+	flag.Var(fields, "field", "field equality key=value, can be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: glogq [flags] file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	q, err := buildQuery(*since, *until, *level, *msg, fields)
+	if err != nil {
+		fatal(err)
+	}
+	if *output != "text" && *output != "json" {
+		fatal(fmt.Errorf("unknown output format: %v", *output))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	emit := func(r *glog.Record) error {
+		if *output == "json" {
+			return enc.Encode(recordJSON(r))
+		}
+		_, err := fmt.Fprintln(os.Stdout, r.Raw)
+		return err
+	}
+
+	reader := glog.NewLogReader(&glog.LogFile{Filename: flag.Arg(0)})
+	reader.TimestampFormat = *layout
+	if *follow {
+		err = reader.Follow(ctx, q, emit)
+	} else {
+		err = reader.Read(ctx, q, emit)
+	}
+	if err != nil && err != context.Canceled {
+		fatal(err)
+	}
+}
+
+func buildQuery(since, until, level, msg string, fields fieldFlags) (glog.Query, error) {
+	var (
+		q   = glog.Query{Fields: fields}
+		err error
+	)
+	if q.Since, err = parseTime(since); err != nil {
+		return q, err
+	}
+	if q.Until, err = parseTime(until); err != nil {
+		return q, err
+	}
+	if level != "" {
+		lvl, err := glog.ParseLevel(level)
+		if err != nil {
+			return q, err
+		}
+		for _, l := range glog.AllLevels {
+			if l <= lvl {
+				q.Levels = append(q.Levels, l)
+			}
+		}
+	}
+	if msg != "" {
+		if q.Message, err = regexp.Compile(msg); err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: %q", s)
+}
+
+func recordJSON(r *glog.Record) map[string]interface{} {
+	m := make(map[string]interface{}, len(r.Data)+4)
+	for _, v := range r.Data {
+		m[v.Key] = v.Value
+	}
+	m[glog.FieldKeyTime] = r.Time.Format(time.RFC3339)
+	m[glog.FieldKeyLevel] = r.Level.String()
+	m[glog.FieldKeyMsg] = r.Message
+	m["_source"] = r.File
+
+	return m
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "glogq:", err)
+	os.Exit(1)
+}
@@ -6,10 +6,14 @@ const (
 	CodeCommon       = 20001
 	CodeUnauthorized = 40001
 	CodeNotExist     = 40004
+	CodePanic        = 50001
+	CodeUnavailable  = 50003
 )
 
 var (
 	CommonError       = &Error{code: CodeCommon, error: new("通用错误")}
 	UnauthorizedError = &Error{code: CodeUnauthorized, error: new("用户未授权")}
 	DataNotExistError = &Error{code: CodeNotExist, error: new("数据不存在")}
+	PanicError        = &Error{code: CodePanic, error: new("服务内部错误")}
+	UnavailableError  = &Error{code: CodeUnavailable, error: new("服务暂不可用")}
 )
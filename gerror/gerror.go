@@ -60,6 +60,17 @@ func Wrapf(err error, format string, args ...interface{}) CodeError {
 	return withMessage(err, fmt.Sprintf(format, args...))
 }
 
+// WrapWithCode 包装错误并指定错误码
+func WrapWithCode(err error, code Code, msg string) CodeError {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		code:  code,
+		error: newEntry(err, msg),
+	}
+}
+
 func Cause(err error) error {
 	type causer interface {
 		Cause() error
@@ -0,0 +1,98 @@
+package ghttp
+
+import (
+	"net/http"
+)
+
+// Authenticator 在请求发送前设置认证信息，传入的请求是副本，可以直接修改
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+type AuthenticatorFunc func(req *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// Refresher 可以刷新认证信息的Authenticator，请求返回401时调用Refresh后重试一次
+type Refresher interface {
+	Refresh(req *http.Request) error // req为返回401的请求
+}
+
+// BasicAuth 使用用户名和密码认证
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// BearerToken 使用固定的token认证
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKeyHeader 在请求头中设置API key
+func APIKeyHeader(header, key string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set(header, key)
+		return nil
+	})
+}
+
+// APIKeyQuery 在查询参数中设置API key
+func APIKeyQuery(param, key string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		// 不修改原请求的URL
+		u := *req.URL
+		query := u.Query()
+		query.Set(param, key)
+		u.RawQuery = query.Encode()
+		req.URL = &u
+		return nil
+	})
+}
+
+// 在最内层设置认证信息，重试和中间件都会使用最新的认证信息
+func authenticate(auth Authenticator, next Doer) Doer {
+	return DoerFunc(func(req *http.Request) (*http.Response, error) {
+		r := req.Clone(req.Context())
+		if err := auth.Authenticate(r); err != nil {
+			return nil, err
+		}
+		res, err := next.Do(r)
+		if err != nil || res.StatusCode != http.StatusUnauthorized {
+			return res, err
+		}
+
+		refresher, ok := auth.(Refresher)
+		if !ok {
+			return res, err
+		}
+		// 请求体无法重新读取时不重试
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return res, err
+		}
+		if errRefresh := refresher.Refresh(r); errRefresh != nil {
+			return res, err
+		}
+		discardResponse(res)
+
+		r = req.Clone(req.Context())
+		if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+			body, errBody := req.GetBody()
+			if errBody != nil {
+				return nil, errBody
+			}
+			r.Body = body
+		}
+		if err = auth.Authenticate(r); err != nil {
+			return nil, err
+		}
+		return next.Do(r)
+	})
+}
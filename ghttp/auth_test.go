@@ -0,0 +1,168 @@
+package ghttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStaticAuthenticators(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key") + "|" + r.URL.Query().Get("key")))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		auth Authenticator
+		want string
+	}{
+		{BasicAuth("user", "pass"), "Basic dXNlcjpwYXNz||"},
+		{BearerToken("t1"), "Bearer t1||"},
+		{APIKeyHeader("X-Api-Key", "k1"), "|k1|"},
+		{APIKeyQuery("key", "k2"), "||k2"},
+	}
+	for _, tt := range tests {
+		c := NewClient(WithBaseURL(ts.URL), WithAuthenticator(tt.auth))
+		req, _ := c.NewRequest(context.Background(), http.MethodGet, "/?page=1", nil)
+		body, err := c.Request(req)
+		if err != nil || string(body) != tt.want {
+			t.Fatalf("body = %q, want %q, err = %v", body, tt.want, err)
+		}
+		if req.URL.RawQuery != "page=1" || req.Header.Get("Authorization") != "" {
+			t.Fatal("authenticator must not modify the caller's request")
+		}
+	}
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var (
+		issued  atomic.Int32
+		revoked sync.Map
+	)
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// 模拟较慢的token接口，验证并发请求只获取一次token
+		time.Sleep(20 * time.Millisecond)
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if _, ok := revoked.Load(token); ok || token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(token))
+	}))
+	defer api.Close()
+
+	auth := NewOAuth2ClientCredentials(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	c := NewClient(WithBaseURL(api.URL), WithAuthenticator(auth))
+
+	send := func() (string, error) {
+		req, _ := c.NewRequest(context.Background(), http.MethodPost, "/", map[string]int{"a": 1})
+		body, err := c.Request(req)
+		return string(body), err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := send(); err != nil || body != "token-1" {
+				t.Errorf("body = %q, err = %v", body, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := issued.Load(); n != 1 {
+		t.Fatalf("token issued %d times, want 1", n)
+	}
+
+	// token被服务端撤销后收到401，刷新token并重试一次
+	revoked.Store("token-1", true)
+	if body, err := send(); err != nil || body != "token-2" {
+		t.Fatalf("after revoke: body = %q, err = %v", body, err)
+	}
+	if n := issued.Load(); n != 2 {
+		t.Fatalf("token issued %d times, want 2", n)
+	}
+
+	// 即将过期的token会被刷新
+	auth.mu.Lock()
+	auth.token.Expiry = time.Now().Add(5 * time.Second)
+	auth.mu.Unlock()
+	if body, err := send(); err != nil || body != "token-3" {
+		t.Fatalf("near expiry: body = %q, err = %v", body, err)
+	}
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer tokenServer.Close()
+
+	c := NewClient(WithAuthenticator(NewOAuth2ClientCredentials(OAuth2Config{TokenURL: tokenServer.URL, AuthInParams: true})))
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, tokenServer.URL, nil)
+	if _, err := c.Request(req); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Fatalf("err = %v, want token endpoint error", err)
+	}
+}
+
+func TestOAuth2RequestOverrides(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"t1","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<result><code>0</code><token>` + r.Header.Get("Authorization") + `</token></result>`))
+	}))
+	defer api.Close()
+
+	type result struct {
+		Code  int    `xml:"code"`
+		Token string `xml:"token"`
+	}
+	// 只接受XML信封的成功判断和XML编解码器不能作用于token接口
+	envelope := func(res *http.Response, body []byte) bool {
+		return strings.HasPrefix(string(body), "<result><code>0</code>")
+	}
+	c := NewClient(WithBaseURL(api.URL), WithAuthenticator(NewOAuth2ClientCredentials(OAuth2Config{TokenURL: tokenServer.URL})))
+
+	res, meta, err := Get[result](context.Background(), c, "/", WithRequestSuccess(envelope), WithRequestCodec(XMLCodec{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Token != "Bearer t1" || meta.Attempts != 1 || meta.Header.Get("Content-Type") != "application/xml" {
+		t.Fatalf("result = %+v, meta = %+v", res, meta)
+	}
+}
@@ -0,0 +1,397 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yueluoa/infrastructure/gerror"
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+const (
+	defaultBreakerWindow       = 10 * time.Second
+	defaultBreakerBuckets      = 10
+	defaultBreakerMinRequests  = 20
+	defaultBreakerFailureRatio = 0.5
+	defaultBreakerConsecutive  = 5
+	defaultBreakerOpenTimeout  = 30 * time.Second
+)
+
+// ErrCircuitOpen 熔断器打开时请求直接失败，不会发送
+var ErrCircuitOpen = gerror.WithCode(gerror.CodeUnavailable, "熔断器已打开")
+
+type CircuitState int
+
+const (
+	StateClosed CircuitState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig 熔断器配置，零值字段使用默认值
+type BreakerConfig struct {
+	Window              time.Duration // 统计失败率的滚动窗口，默认为10秒
+	MinRequests         int           // 窗口内请求数达到该值后才按失败率熔断，默认为20
+	FailureRatio        float64       // 窗口内失败率达到该值时熔断，默认为0.5
+	ConsecutiveFailures int           // 连续失败次数达到该值时熔断，默认为5，小于0时不按连续失败熔断
+	OpenTimeout         time.Duration // 熔断后多久进入半开状态，默认为30秒
+	HalfOpenRequests    int           // 半开状态允许的探测请求数，全部成功后恢复，默认为1
+
+	// Key 熔断的维度，默认为HostKey；每个key会保存一个熔断器，应当是host或路由模板这类有限的值，
+	// 空闲超过Window的关闭状态熔断器会被清理
+	Key       func(req *http.Request) string
+	IsFailure func(res *http.Response, err error) bool // 默认请求出错或状态码为5xx时失败
+	// Ignore 忽略的请求结果，既不算成功也不算失败，半开状态下会释放探测名额，默认忽略context取消
+	Ignore        func(res *http.Response, err error) bool
+	OnStateChange func(key string, from, to CircuitState) // 状态变化时调用
+}
+
+// HostKey 按host熔断
+func HostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// RouteKey 按方法、host和路径熔断，路径中包含ID等变化的值时应当自定义Key使用路由模板
+func RouteKey(req *http.Request) string {
+	return req.Method + " " + req.URL.Host + req.URL.Path
+}
+
+// LogStateChange 使用glog记录熔断器的状态变化，打开时为warn级别，其他为info级别
+func LogStateChange(log *glog.Log) func(key string, from, to CircuitState) {
+	return func(key string, from, to CircuitState) {
+		entry := log.WithFields([]glog.Field{
+			{Key: "breaker", Value: key},
+			{Key: "from", Value: from.String()},
+			{Key: "to", Value: to.String()},
+		})
+		if to == StateOpen {
+			entry.Warn("circuit breaker opened")
+			return
+		}
+		entry.Info("circuit breaker state changed")
+	}
+}
+
+func defaultIsFailure(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= http.StatusInternalServerError
+}
+
+func defaultIgnore(_ *http.Response, err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// CircuitBreaker 按Key分别熔断的熔断器
+type CircuitBreaker struct {
+	config    BreakerConfig
+	mu        sync.Mutex
+	breakers  map[string]*breaker
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	if config.Window <= 0 {
+		config.Window = defaultBreakerWindow
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaultBreakerMinRequests
+	}
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = defaultBreakerFailureRatio
+	}
+	if config.ConsecutiveFailures == 0 {
+		config.ConsecutiveFailures = defaultBreakerConsecutive
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	if config.Key == nil {
+		config.Key = HostKey
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = defaultIsFailure
+	}
+	if config.Ignore == nil {
+		config.Ignore = defaultIgnore
+	}
+
+	return &CircuitBreaker{
+		config:   config,
+		breakers: make(map[string]*breaker),
+		now:      time.Now,
+	}
+}
+
+// WithCircuitBreaker 添加熔断中间件
+func WithCircuitBreaker(config BreakerConfig) Option {
+	return WithMiddleware(NewCircuitBreaker(config).Middleware())
+}
+
+// Middleware 熔断中间件，熔断时返回包装了ErrCircuitOpen的错误
+func (cb *CircuitBreaker) Middleware() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			key := cb.config.Key(req)
+			b := cb.breaker(key)
+
+			generation, ok := b.allow(cb)
+			if !ok {
+				return nil, gerror.Wrapf(ErrCircuitOpen, "http circuit open, key=%v", key)
+			}
+			res, err := next.Do(req)
+			if cb.config.Ignore(res, err) {
+				b.release(cb, generation)
+			} else {
+				b.record(cb, generation, cb.config.IsFailure(res, err))
+			}
+
+			return res, err
+		})
+	}
+}
+
+// State 返回key当前的状态
+func (cb *CircuitBreaker) State(key string) CircuitState {
+	cb.mu.Lock()
+	b, ok := cb.breakers[key]
+	cb.mu.Unlock()
+	if !ok {
+		return StateClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update(cb)
+	return b.state
+}
+
+func (cb *CircuitBreaker) breaker(key string) *breaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// 每个Window清理一次空闲的熔断器，避免key过多时占用内存
+	now := cb.now()
+	if now.Sub(cb.lastSweep) > cb.config.Window {
+		for k, b := range cb.breakers {
+			if b.idle(now, cb.config.Window) {
+				delete(cb.breakers, k)
+			}
+		}
+		cb.lastSweep = now
+	}
+
+	// 在cb.mu中更新lastUsed，避免返回后、allow之前被其他请求当作空闲的熔断器清理
+	b, ok := cb.breakers[key]
+	if !ok {
+		b = &breaker{
+			key:      key,
+			buckets:  make([]bucket, defaultBreakerBuckets),
+			lastUsed: now,
+		}
+		cb.breakers[key] = b
+		return b
+	}
+	b.mu.Lock()
+	b.lastUsed = now
+	b.mu.Unlock()
+	return b
+}
+
+type bucket struct {
+	epoch    int64
+	total    int
+	failures int
+}
+
+type breaker struct {
+	key         string
+	mu          sync.Mutex
+	state       CircuitState
+	generation  uint64 // 每次状态变化加1，忽略之前状态下发出的请求结果
+	buckets     []bucket
+	consecutive int
+	openedAt    time.Time
+	probes      int // 半开状态已放行的请求数
+	successes   int // 半开状态成功的请求数
+	inflight    int
+	lastUsed    time.Time
+	changes     []stateChange
+}
+
+type stateChange struct {
+	from, to CircuitState
+}
+
+func (b *breaker) allow(cb *CircuitBreaker) (uint64, bool) {
+	b.mu.Lock()
+	b.update(cb)
+
+	allowed := true
+	switch b.state {
+	case StateOpen:
+		allowed = false
+	case StateHalfOpen:
+		if b.probes >= cb.config.HalfOpenRequests {
+			allowed = false
+		} else {
+			b.probes++
+		}
+	}
+	if allowed {
+		b.inflight++
+	}
+	b.lastUsed = cb.now()
+	generation := b.generation
+	b.unlock(cb)
+
+	return generation, allowed
+}
+
+// 关闭状态且超过window没有请求
+func (b *breaker) idle(now time.Time, window time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == StateClosed && b.inflight == 0 && now.Sub(b.lastUsed) > window
+}
+
+// 忽略请求结果，半开状态下释放探测名额
+func (b *breaker) release(cb *CircuitBreaker, generation uint64) {
+	b.mu.Lock()
+	defer b.unlock(cb)
+
+	b.inflight--
+	b.update(cb)
+	if generation == b.generation && b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *breaker) record(cb *CircuitBreaker, generation uint64, failure bool) {
+	b.mu.Lock()
+	defer b.unlock(cb)
+
+	b.inflight--
+	b.update(cb)
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		bkt := b.bucket(cb)
+		bkt.total++
+		if !failure {
+			b.consecutive = 0
+			return
+		}
+		bkt.failures++
+		b.consecutive++
+		if cb.config.ConsecutiveFailures > 0 && b.consecutive >= cb.config.ConsecutiveFailures {
+			b.setState(cb, StateOpen)
+			return
+		}
+		total, failures := b.counts(cb)
+		if total >= cb.config.MinRequests && float64(failures)/float64(total) >= cb.config.FailureRatio {
+			b.setState(cb, StateOpen)
+		}
+	case StateHalfOpen:
+		if failure {
+			b.setState(cb, StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= cb.config.HalfOpenRequests {
+			b.setState(cb, StateClosed)
+		}
+	}
+}
+
+// 熔断超时后进入半开状态
+func (b *breaker) update(cb *CircuitBreaker) {
+	if b.state == StateOpen && !cb.now().Before(b.openedAt.Add(cb.config.OpenTimeout)) {
+		b.setState(cb, StateHalfOpen)
+	}
+}
+
+func (b *breaker) setState(cb *CircuitBreaker, state CircuitState) {
+	if b.state == state {
+		return
+	}
+	b.changes = append(b.changes, stateChange{from: b.state, to: state})
+	b.state = state
+	b.generation++
+	b.consecutive = 0
+	b.probes = 0
+	b.successes = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = cb.now()
+	case StateClosed:
+		for i := range b.buckets {
+			b.buckets[i] = bucket{}
+		}
+	}
+}
+
+// 释放锁后再调用回调，回调中可以调用State
+func (b *breaker) unlock(cb *CircuitBreaker) {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	if cb.config.OnStateChange == nil {
+		return
+	}
+	for _, c := range changes {
+		cb.config.OnStateChange(b.key, c.from, c.to)
+	}
+}
+
+func (b *breaker) bucketSize(cb *CircuitBreaker) int64 {
+	size := int64(cb.config.Window) / int64(len(b.buckets))
+	if size <= 0 {
+		size = 1
+	}
+	return size
+}
+
+// 返回当前时间所在的统计桶，过期的桶会被重置
+func (b *breaker) bucket(cb *CircuitBreaker) *bucket {
+	epoch := cb.now().UnixNano() / b.bucketSize(cb)
+	bkt := &b.buckets[epoch%int64(len(b.buckets))]
+	if bkt.epoch != epoch {
+		*bkt = bucket{epoch: epoch}
+	}
+	return bkt
+}
+
+// 返回滚动窗口内的请求数和失败数
+func (b *breaker) counts(cb *CircuitBreaker) (int, int) {
+	current := cb.now().UnixNano() / b.bucketSize(cb)
+	var total, failures int
+	for _, bkt := range b.buckets {
+		if current-bkt.epoch < int64(len(b.buckets)) {
+			total += bkt.total
+			failures += bkt.failures
+		}
+	}
+	return total, failures
+}
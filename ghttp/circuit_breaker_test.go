@@ -0,0 +1,211 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		failing atomic.Bool
+		hits    atomic.Int32
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var changes []string
+	now := time.Unix(1700000000, 0)
+	cb := NewCircuitBreaker(BreakerConfig{
+		ConsecutiveFailures: 3,
+		OpenTimeout:         time.Minute,
+		HalfOpenRequests:    2,
+		OnStateChange: func(key string, from, to CircuitState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	})
+	cb.now = func() time.Time { return now }
+
+	c := NewClient(WithBaseURL(ts.URL), WithMiddleware(cb.Middleware()))
+	send := func() error {
+		req, _ := c.NewRequest(context.Background(), http.MethodGet, "/", nil)
+		_, err := c.SendRequestRaw(req)
+		return err
+	}
+	key := strings.TrimPrefix(ts.URL, "http://")
+
+	failing.Store(true)
+	for i := 0; i < 3; i++ {
+		if err := send(); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if state := cb.State(key); state != StateOpen {
+		t.Fatalf("state = %v, want open", state)
+	}
+
+	err := send()
+	if !errors.Is(err, ErrCircuitOpen) || !gerror.IsWithCode(gerror.CodeUnavailable, err) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("hits = %d, open circuit should not send", hits.Load())
+	}
+
+	// 半开状态探测失败后重新打开
+	now = now.Add(time.Minute)
+	if err := send(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want probe failure", err)
+	}
+	if state := cb.State(key); state != StateOpen {
+		t.Fatalf("state = %v, want open", state)
+	}
+
+	// 探测请求全部成功后恢复
+	now = now.Add(time.Minute)
+	failing.Store(false)
+	for i := 0; i < 2; i++ {
+		if err := send(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state := cb.State(key); state != StateClosed {
+		t.Fatalf("state = %v, want closed", state)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v", changes)
+		}
+	}
+}
+
+func TestCircuitBreakerFailureRatio(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cb := NewCircuitBreaker(BreakerConfig{
+		Window:              10 * time.Second,
+		MinRequests:         4,
+		FailureRatio:        0.5,
+		ConsecutiveFailures: -1,
+		Key:                 RouteKey,
+	})
+	cb.now = func() time.Time { return now }
+
+	status := http.StatusOK
+	doer := cb.Middleware()(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+	}))
+	do := func(path string) error {
+		req, _ := http.NewRequest(http.MethodGet, "http://api.example.com"+path, nil)
+		_, err := doer.Do(req)
+		return err
+	}
+
+	// 过期的失败不计入窗口
+	status = http.StatusBadGateway
+	_ = do("/a")
+	_ = do("/a")
+	now = now.Add(11 * time.Second)
+	status = http.StatusOK
+	_ = do("/a")
+	_ = do("/a")
+	status = http.StatusBadGateway
+	_ = do("/a")
+	if state := cb.State("GET api.example.com/a"); state != StateClosed {
+		t.Fatalf("state = %v, want closed", state)
+	}
+
+	_ = do("/a")
+	if state := cb.State("GET api.example.com/a"); state != StateOpen {
+		t.Fatalf("state = %v, want open", state)
+	}
+	if err := do("/a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v", err)
+	}
+	// 其他路由不受影响
+	status = http.StatusOK
+	if err := do("/b"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCircuitBreakerIgnoreAndEvict(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cb := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Second, Key: RouteKey})
+	cb.now = func() time.Time { return now }
+
+	var err error
+	doer := cb.Middleware()(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	do := func(path string) error {
+		req, _ := http.NewRequest(http.MethodGet, "http://api.example.com"+path, nil)
+		_, e := doer.Do(req)
+		return e
+	}
+
+	err = errors.New("connection refused")
+	_ = do("/a")
+	if state := cb.State("GET api.example.com/a"); state != StateOpen {
+		t.Fatalf("state = %v, want open", state)
+	}
+
+	// 取消的探测请求不会恢复熔断器，并释放探测名额
+	now = now.Add(time.Second)
+	err = context.Canceled
+	if e := do("/a"); !errors.Is(e, context.Canceled) {
+		t.Fatalf("err = %v", e)
+	}
+	if state := cb.State("GET api.example.com/a"); state != StateHalfOpen {
+		t.Fatalf("state = %v, want half-open", state)
+	}
+	err = nil
+	if e := do("/a"); e != nil {
+		t.Fatalf("probe after cancel: %v", e)
+	}
+	if state := cb.State("GET api.example.com/a"); state != StateClosed {
+		t.Fatalf("state = %v, want closed", state)
+	}
+
+	// 空闲的关闭状态熔断器会被清理
+	for i := 0; i < 100; i++ {
+		_ = do("/users/" + strconv.Itoa(i))
+	}
+	now = now.Add(time.Minute)
+	_ = do("/b")
+	cb.mu.Lock()
+	n := len(cb.breakers)
+	cb.mu.Unlock()
+	if n != 1 {
+		t.Fatalf("breakers = %d, want 1", n)
+	}
+	// 刚取得、还没有调用allow的熔断器不会被清理
+	now = now.Add(time.Minute)
+	b := cb.breaker("new")
+	cb.lastSweep = time.Time{}
+	if cb.breaker("other"); cb.breakers["new"] != b {
+		t.Fatal("breaker evicted before allow")
+	}
+}
@@ -2,10 +2,8 @@ package ghttp
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
+	"time"
 )
 
 type Client struct {
@@ -36,20 +34,14 @@ func NewClientWithConfig(config ClientConfig) *Client {
 }
 
 func (c *Client) SendRequest(req *http.Request, v interface{}) error {
-	res, err := c.config.Client.Do(req)
+	res, byteBody, err := c.send(req)
 	if err != nil {
 		return err
 	}
 
-	defer res.Body.Close()
-
-	byteBody, _ := io.ReadAll(res.Body)
-	if res.StatusCode != http.StatusOK {
-		return c.httpCodeError(req.URL.String(), res.StatusCode, string(byteBody))
-	}
-
-	if v != nil {
-		if err = json.Unmarshal(byteBody, &v); err != nil {
+	if v != nil && !emptyBody(byteBody) {
+		codec := c.responseCodec(req.Context(), res.Header.Get("Content-Type"))
+		if err = codec.Unmarshal(byteBody, v); err != nil {
 			return err
 		}
 	}
@@ -60,53 +52,103 @@ func (c *Client) SendRequest(req *http.Request, v interface{}) error {
 func (c *Client) SendRequestRaw(req *http.Request) (*RawResponse, error) {
 	var response = &RawResponse{}
 
-	res, err := c.config.Client.Do(req)
+	_, byteBody, err := c.send(req)
 	if err != nil {
 		return response, err
 	}
 
-	defer res.Body.Close()
-
-	byteBody, _ := io.ReadAll(res.Body)
-	if res.StatusCode != http.StatusOK {
-		return response, c.httpCodeError(req.URL.String(), res.StatusCode, string(byteBody))
-	}
-
 	response.Body = string(byteBody)
 
 	return response, nil
 }
 
 func (c *Client) Request(req *http.Request) ([]byte, error) {
+	_, byteBody, err := c.send(req)
+	return byteBody, err
+}
+
+// 发送请求并读取响应体，请求不成功时返回*HTTPError；返回的响应体已关闭
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	res, meta, err := c.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer res.Body.Close()
+
+	byteBody, err := c.readBody(req, res)
+	if err != nil {
+		return res, byteBody, err
+	}
+	if !c.success(req, res, byteBody) {
+		return res, byteBody, c.httpCodeError(req, res, meta, byteBody)
+	}
+
+	return res, byteBody, nil
+}
+
+// 发送请求，按照重试策略重试失败的请求
+func (c *Client) do(req *http.Request) (*http.Response, *ResponseMeta, error) {
 	var (
-		byteBody []byte
+		start    = time.Now()
+		ctx      = req.Context()
+		policy   = c.config.Retry
+		attempts = 0
+		res      *http.Response
 		err      error
+		doer     Doer = c.config.Client
 	)
-	res, err := c.config.Client.Do(req)
-	if err != nil {
-		return byteBody, err
+	doer = chain(doer, c.config.Middlewares)
+	// 先设置认证信息再经过中间件，Sign等中间件可以看到认证参数
+	if c.config.Authenticator != nil {
+		doer = authenticate(c.config.Authenticator, doer)
 	}
 
-	defer res.Body.Close()
+	for {
+		attempts++
+		r := req
+		if attempts > 1 && req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+			body, errBody := req.GetBody()
+			if errBody != nil {
+				return nil, nil, errBody
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
 
-	byteBody, _ = io.ReadAll(res.Body)
-	if res.StatusCode != http.StatusOK {
-		return byteBody, c.httpCodeError(req.URL.String(), res.StatusCode, string(byteBody))
+		res, err = doer.Do(r)
+		if policy == nil || attempts >= policy.MaxAttempts || !policy.retryable(req, res, err) {
+			break
+		}
+		if !sleepContext(ctx, policy.wait(attempts, res)) {
+			break
+		}
+		discardResponse(res)
+	}
+
+	meta := &ResponseMeta{Attempts: attempts, Duration: time.Since(start)}
+	if res != nil {
+		meta.StatusCode = res.StatusCode
+		meta.Header = res.Header
+	}
+	if m := metaFromContext(ctx); m != nil {
+		*m = *meta
 	}
 
-	return byteBody, nil
+	return res, meta, err
 }
 
 func (c *Client) NewRequest(ctx context.Context, method string, url string, body interface{}) (*http.Request, error) {
-	req, err := c.requestBuilder.jsonBuild(ctx, method, c.fullURL(url), body)
+	codec := c.requestCodec(ctx)
+	req, err := c.requestBuilder.codecBuild(ctx, method, c.fullURL(url), codec, body)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Accept", "application/json; charset=utf-8")
+	req.Header.Set("Accept", codec.ContentType())
 	contentType := req.Header.Get("Content-Type")
 	if contentType == "" {
-		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Content-Type", codec.ContentType())
 	}
 
 	return req, nil
@@ -127,10 +169,6 @@ func (c *Client) NewEncodedRequest(ctx context.Context, method string, url strin
 	return req, nil
 }
 
-func (c *Client) httpCodeError(url string, statusCode int, errMsg string) error {
-	return fmt.Errorf("http error, url=%v, statusCode=%v, err=%v", url, statusCode, errMsg)
-}
-
 func (c *Client) fullURL(suffix string) string {
-	return fmt.Sprintf("%s%s", c.config.BaseURL, suffix)
+	return joinURL(c.config.BaseURL, suffix)
 }
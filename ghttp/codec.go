@@ -0,0 +1,164 @@
+package ghttp
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	netUrl "net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
+type codecKey struct{}
+
+// Codec 请求体的编码和响应体的解码，例如基于第三方库实现更快的JSON或protobuf
+type Codec interface {
+	ContentType() string // 编码后请求的Content-Type，例如 "application/json; charset=utf-8"
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string { return ContentTypeJSON + "; charset=utf-8" }
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type XMLCodec struct{}
+
+func (XMLCodec) ContentType() string { return ContentTypeXML + "; charset=utf-8" }
+
+func (XMLCodec) Marshal(v interface{}) ([]byte, error) { return xml.Marshal(v) }
+
+func (XMLCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+
+// FormCodec url编码的表单，编码支持EncodeQuery的所有类型，解码支持*url.Values、*map[string]string和*map[string][]string
+type FormCodec struct{}
+
+func (FormCodec) ContentType() string { return ContentTypeForm }
+
+func (FormCodec) Marshal(v interface{}) ([]byte, error) {
+	values, err := EncodeQuery(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (FormCodec) Unmarshal(data []byte, v interface{}) error {
+	values, err := netUrl.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch dst := v.(type) {
+	case *netUrl.Values:
+		*dst = values
+	case *map[string][]string:
+		*dst = values
+	case *map[string]string:
+		m := make(map[string]string, len(values))
+		for key := range values {
+			m[key] = values.Get(key)
+		}
+		*dst = m
+	default:
+		return fmt.Errorf("ghttp: form codec cannot unmarshal into %T", v)
+	}
+	return nil
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		ContentTypeJSON: JSONCodec{},
+		ContentTypeXML:  XMLCodec{},
+		"text/xml":      XMLCodec{},
+		ContentTypeForm: FormCodec{},
+	}
+)
+
+// RegisterCodec 注册Content-Type对应的解码器，所有Client按照响应的Content-Type选择解码器
+func RegisterCodec(contentType string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[mediaType(contentType)] = codec
+}
+
+// ContextWithCodec 使用该context创建的请求使用codec编码请求体和解码响应体，优先于Client的配置
+func ContextWithCodec(ctx context.Context, codec Codec) context.Context {
+	return context.WithValue(ctx, codecKey{}, codec)
+}
+
+func codecFromContext(ctx context.Context) (Codec, bool) {
+	codec, ok := ctx.Value(codecKey{}).(Codec)
+	return codec, ok && codec != nil
+}
+
+// 编码请求体使用的codec
+func (c *Client) requestCodec(ctx context.Context) Codec {
+	if codec, ok := codecFromContext(ctx); ok {
+		return codec
+	}
+	if c.config.Codec != nil {
+		return c.config.Codec
+	}
+	return JSONCodec{}
+}
+
+// 解码响应体使用的codec，依次使用单个请求指定的codec、Client和全局注册的Content-Type对应的codec、Client的codec
+func (c *Client) responseCodec(ctx context.Context, contentType string) Codec {
+	if codec, ok := codecFromContext(ctx); ok {
+		return codec
+	}
+	if mt := mediaType(contentType); mt != "" {
+		if codec, ok := c.config.Codecs[mt]; ok {
+			return codec
+		}
+		if codec, ok := lookupCodec(mt); ok {
+			return codec
+		}
+	}
+	return c.requestCodec(ctx)
+}
+
+// 解码流中每条JSON数据使用的codec，依次使用单个请求指定的codec、Client和全局注册的JSON codec
+func (c *Client) itemCodec(ctx context.Context) Codec {
+	return c.responseCodec(ctx, ContentTypeJSON)
+}
+
+func lookupCodec(mt string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	if codec, ok := codecs[mt]; ok {
+		return codec, true
+	}
+	// 例如 application/problem+json
+	if i := strings.LastIndexByte(mt, '+'); i >= 0 {
+		switch mt[i+1:] {
+		case "json":
+			return codecs[ContentTypeJSON], true
+		case "xml":
+			return codecs[ContentTypeXML], true
+		}
+	}
+	return nil, false
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
@@ -0,0 +1,92 @@
+package ghttp
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	netUrl "net/url"
+	"testing"
+)
+
+type order struct {
+	XMLName xml.Name `xml:"order" json:"-"`
+	ID      int      `xml:"id" json:"id"`
+	Status  string   `xml:"status" json:"status"`
+}
+
+// 在JSON前添加前缀，模拟自定义的codec
+type prefixCodec struct{}
+
+func (prefixCodec) ContentType() string { return "application/vnd.prefix" }
+
+func (prefixCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	return append([]byte(")]}'"), b...), err
+}
+
+func (prefixCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data[4:], v)
+}
+
+func TestCodec(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xml":
+			w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+			_, _ = w.Write([]byte(`<order><id>1</id><status>paid</status></order>`))
+		case "/problem":
+			w.Header().Set("Content-Type", "application/problem+json")
+			_, _ = w.Write([]byte(`{"id":2,"status":"failed"}`))
+		case "/form":
+			w.Header().Set("Content-Type", ContentTypeForm)
+			_, _ = w.Write([]byte(`token=abc&expires=3600`))
+		case "/echo":
+			w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+			var body [256]byte
+			n, _ := r.Body.Read(body[:])
+			_, _ = w.Write(body[:n])
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+	ctx := context.Background()
+
+	var o order
+	req, _ := c.NewRequest(ctx, http.MethodGet, "/xml", nil)
+	if err := c.SendRequest(req, &o); err != nil || o.ID != 1 || o.Status != "paid" {
+		t.Fatalf("xml = %+v, %v", o, err)
+	}
+	o, _, err := Get[order](ctx, c, "/problem")
+	if err != nil || o.ID != 2 {
+		t.Fatalf("problem+json = %+v, %v", o, err)
+	}
+	form, _, err := Get[netUrl.Values](ctx, c, "/form")
+	if err != nil || form.Get("token") != "abc" {
+		t.Fatalf("form = %v, %v", form, err)
+	}
+
+	// 单个请求指定codec编码请求体
+	o, _, err = Post[order, order](ctx, c, "/echo", order{ID: 3, Status: "new"}, WithRequestCodec(XMLCodec{}))
+	if err != nil || o.ID != 3 || o.Status != "new" {
+		t.Fatalf("xml echo = %+v, %v", o, err)
+	}
+
+	// Client指定codec
+	c = NewClient(WithBaseURL(ts.URL), WithCodec(prefixCodec{}))
+	req, _ = c.NewRequest(ctx, http.MethodPost, "/echo", order{ID: 4})
+	if req.Header.Get("Content-Type") != "application/vnd.prefix" {
+		t.Fatalf("content type = %q", req.Header.Get("Content-Type"))
+	}
+	o = order{}
+	if err = c.SendRequest(req, &o); err != nil || o.ID != 4 {
+		t.Fatalf("custom codec = %+v, %v", o, err)
+	}
+
+	b, err := FormCodec{}.Marshal(map[string]string{"a": "1"})
+	if err != nil || string(b) != "a=1" {
+		t.Fatalf("form marshal = %q, %v", b, err)
+	}
+}
@@ -1,10 +1,24 @@
 package ghttp
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
 
 type ClientConfig struct {
-	Client  *http.Client
-	BaseURL string
+	Client        *http.Client
+	BaseURL       string
+	Retry         *RetryPolicy        // 为nil时不重试
+	ErrorCodes    map[int]gerror.Code // HTTPError的状态码与错误码对应关系，优先于默认的对应关系
+	ErrorBody     func() interface{}  // 返回用于解析JSON错误响应体的指针
+	Success       SuccessFunc         // 判断请求是否成功，为nil时状态码为2xx即成功
+	StreamSuccess SuccessFunc         // 判断Stream、SSE、NDJSON的响应是否成功，body为nil，为nil时状态码为2xx即成功；不使用Success
+	MaxBodySize   int64               // SendRequest、SendRequestRaw和Request读取响应体的最大长度，0为不限制
+	Codec         Codec               // 编码请求体，响应的Content-Type没有对应的codec时也用于解码，默认为JSONCodec
+	Codecs        map[string]Codec    // 响应的Content-Type对应的codec，优先于RegisterCodec注册的codec
+	Authenticator Authenticator       // 在中间件之前设置认证信息，设置的请求头和查询参数会经过中间件，例如参与Sign签名
+	Middlewares   []Middleware        // 发送请求的中间件
 }
 
 func DefaultConfig() ClientConfig {
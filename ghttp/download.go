@@ -0,0 +1,246 @@
+package ghttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	partialSuffix   = ".part"
+	validatorSuffix = ".part.validator" // 保存临时文件对应的ETag或Last-Modified，续传时作为If-Range
+)
+
+// ErrChecksumMismatch 下载文件的校验值与期望值不一致
+var ErrChecksumMismatch = errors.New("ghttp: checksum mismatch")
+
+// DownloadOption 下载文件的配置
+type DownloadOption interface {
+	apply(*download)
+}
+
+type downloadOptionFunc func(*download)
+
+func (f downloadOptionFunc) apply(d *download) {
+	f(d)
+}
+
+type download struct {
+	newHash  func() hash.Hash
+	checksum string
+	progress func(written, total int64)
+	noResume bool
+}
+
+// WithChecksum 下载完成后使用newHash计算文件的校验值，与十六进制的expected不一致时返回ErrChecksumMismatch
+func WithChecksum(newHash func() hash.Hash, expected string) DownloadOption {
+	return downloadOptionFunc(func(d *download) {
+		d.newHash = newHash
+		d.checksum = strings.ToLower(expected)
+	})
+}
+
+func WithSHA256(expected string) DownloadOption {
+	return WithChecksum(sha256.New, expected)
+}
+
+func WithMD5(expected string) DownloadOption {
+	return WithChecksum(md5.New, expected)
+}
+
+// WithDownloadProgress 下载过程中回调已下载的字节数(包括续传前已下载的部分)，total未知时为-1
+func WithDownloadProgress(fn func(written, total int64)) DownloadOption {
+	return downloadOptionFunc(func(d *download) {
+		d.progress = fn
+	})
+}
+
+// WithoutResume 不使用上次未完成的临时文件续传
+func WithoutResume() DownloadOption {
+	return downloadOptionFunc(func(d *download) {
+		d.noResume = true
+	})
+}
+
+// DownloadToFile 下载文件到path，先写入path.part临时文件，完成并校验后重命名；
+// 临时文件已存在时通过Range和If-Range请求续传，服务端不支持或文件已经变化时重新下载
+func (c *Client) DownloadToFile(ctx context.Context, url string, path string, opts ...DownloadOption) error {
+	d := &download{}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	partial := path + partialSuffix
+	validatorPath := path + validatorSuffix
+
+	var (
+		offset    int64
+		validator string
+	)
+	if !d.noResume {
+		// 没有保存ETag或Last-Modified时无法确认文件是否变化，重新下载
+		if b, err := os.ReadFile(validatorPath); err == nil && len(b) > 0 {
+			if info, err := os.Stat(partial); err == nil {
+				offset, validator = info.Size(), string(b)
+			}
+		}
+	}
+
+	res, offset, err := c.openDownload(ctx, url, offset, validator)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if offset == 0 {
+		if v := responseValidator(res); v != "" {
+			err = os.WriteFile(validatorPath, []byte(v), 0644)
+		} else {
+			err = os.Remove(validatorPath)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(partial, flag, 0644)
+	if err != nil {
+		return err
+	}
+
+	total := int64(-1)
+	if res.ContentLength >= 0 {
+		total = offset + res.ContentLength
+	}
+	var w io.Writer = f
+	if d.progress != nil {
+		pw := &progressWriter{w: f, total: total, fn: d.progress}
+		pw.written.Store(offset)
+		w = pw
+	}
+
+	n, err := io.Copy(w, res.Body)
+	if err == nil && res.ContentLength >= 0 && n != res.ContentLength {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	// 保留临时文件用于续传
+	if err != nil {
+		return fmt.Errorf("http download error, url=%v, err=%w", res.Request.URL.String(), err)
+	}
+
+	if d.newHash != nil {
+		if err = verifyChecksum(partial, d.newHash(), d.checksum); err != nil {
+			_ = os.Remove(partial)
+			_ = os.Remove(validatorPath)
+			return err
+		}
+	}
+
+	if err = os.Rename(partial, path); err != nil {
+		return err
+	}
+	_ = os.Remove(validatorPath)
+	return nil
+}
+
+// 发送下载请求，offset大于0时请求剩余部分，文件与validator不一致时服务端返回完整的文件，返回实际的起始位置
+func (c *Client) openDownload(ctx context.Context, url string, offset int64, validator string) (*http.Response, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.fullURL(url), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		req.Header.Set("If-Range", validator)
+	}
+
+	res, meta, err := c.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// 服务端不支持Range时重新下载
+		return res, 0, nil
+	case http.StatusPartialContent:
+		// 服务端忽略If-Range时通过响应的ETag或Last-Modified确认文件没有变化
+		if offset > 0 && contentRangeStart(res.Header.Get("Content-Range")) == offset {
+			if v := responseValidator(res); v == "" || v == validator {
+				return res, offset, nil
+			}
+		}
+		fallthrough
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 返回的范围不对或临时文件比服务端的文件大，重新下载
+		if offset > 0 {
+			discardResponse(res)
+			return c.openDownload(ctx, url, 0, "")
+		}
+	}
+
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	return nil, 0, c.httpCodeError(req, res, meta, body)
+}
+
+// 返回可以用于If-Range的强ETag或Last-Modified
+func responseValidator(res *http.Response) string {
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return res.Header.Get("Last-Modified")
+}
+
+// 解析 Content-Range: bytes 100-199/200 中的起始位置
+func contentRangeStart(v string) int64 {
+	v = strings.TrimPrefix(v, "bytes ")
+	start, _, ok := strings.Cut(v, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func verifyChecksum(path string, h hash.Hash, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(h, f); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("%w, file=%v, expected=%v, actual=%v", ErrChecksumMismatch, path, expected, actual)
+	}
+	return nil
+}
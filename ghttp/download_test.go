@@ -0,0 +1,149 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadToFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	var ranges []string
+	etag := `"v1"`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "export.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "export.bin")
+
+	// 模拟上次下载中断，只保存了前4000字节
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+partialSuffix, content[:4000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+validatorSuffix, []byte(etag), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var written, total int64
+	err := c.DownloadToFile(context.Background(), "/export", path,
+		WithSHA256(checksum),
+		WithDownloadProgress(func(w, t int64) { written, total = w, t }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, content) {
+		t.Fatalf("downloaded %d bytes, want %d", len(got), len(content))
+	}
+	if ranges[0] != "bytes=4000-" || written != int64(len(content)) || total != int64(len(content)) {
+		t.Fatalf("range = %q, progress = %d/%d", ranges[0], written, total)
+	}
+	if _, err = os.Stat(path + partialSuffix); !os.IsNotExist(err) {
+		t.Fatal("partial file not renamed")
+	}
+	if _, err = os.Stat(path + validatorSuffix); !os.IsNotExist(err) {
+		t.Fatal("validator file not removed")
+	}
+
+	// 服务端文件变化后If-Range不匹配，重新下载完整的文件而不是拼接
+	_ = os.WriteFile(path+partialSuffix, bytes.Repeat([]byte("x"), 4000), 0644)
+	_ = os.WriteFile(path+validatorSuffix, []byte(`"v0"`), 0644)
+	if err = c.DownloadToFile(context.Background(), "/export", path, WithSHA256(checksum)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有保存ETag时不续传
+	ranges = nil
+	_ = os.WriteFile(path+partialSuffix, content[:4000], 0644)
+	if err = c.DownloadToFile(context.Background(), "/export", path, WithSHA256(checksum)); err != nil || ranges[0] != "" {
+		t.Fatalf("range = %q, err = %v", ranges[0], err)
+	}
+
+	// 校验失败时删除临时文件，不覆盖已有文件
+	err = c.DownloadToFile(context.Background(), "/export", path, WithMD5("00"))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err = os.Stat(path + partialSuffix); !os.IsNotExist(err) {
+		t.Fatal("partial file should be removed after checksum mismatch")
+	}
+
+	// 临时文件比服务端的文件大时重新下载
+	if err = os.WriteFile(path+partialSuffix, bytes.Repeat([]byte("x"), 20000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(path+validatorSuffix, []byte(etag), 0644)
+	if err = c.DownloadToFile(context.Background(), "/export", path, WithSHA256(checksum)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamAndMaxBodySize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+			return
+		}
+		// 不设置Content-Length，分块发送
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithMaxBodySize(1024))
+
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/big", nil)
+	if _, err := c.Request(req); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("err = %v, want ErrBodyTooLarge", err)
+	}
+
+	// Stream不受MaxBodySize限制
+	req, _ = c.NewRequest(context.Background(), http.MethodGet, "/big", nil)
+	res, err := c.Stream(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(res.Body)
+	_ = res.Body.Close()
+	if buf.Len() != 2048 {
+		t.Fatalf("streamed %d bytes, want 2048", buf.Len())
+	}
+
+	req, _ = c.NewRequest(context.Background(), http.MethodGet, "/missing", nil)
+	var httpErr *HTTPError
+	if _, err = c.Stream(req); !errors.As(err, &httpErr) || string(httpErr.Body) != "not found" {
+		t.Fatalf("err = %v, want 404 *HTTPError", err)
+	}
+
+	// 检查响应体的Success不用于流式响应
+	envelope := NewClient(WithBaseURL(ts.URL), WithSuccess(func(res *http.Response, body []byte) bool {
+		return bytes.HasPrefix(body, []byte("a"))
+	}))
+	req, _ = envelope.NewRequest(context.Background(), http.MethodGet, "/big", nil)
+	if res, err = envelope.Stream(req); err != nil {
+		t.Fatalf("stream with body success: %v", err)
+	}
+	_ = res.Body.Close()
+}
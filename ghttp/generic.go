@@ -0,0 +1,76 @@
+package ghttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// Get 发送GET请求并解析响应为T
+//
+//	user, meta, err := ghttp.Get[User](ctx, client, "/users/{id}", ghttp.WithPathParam("id", "1"), ghttp.WithTimeout(time.Second))
+func Get[T any](ctx context.Context, c *Client, path string, opts ...RequestOption) (T, *ResponseMeta, error) {
+	return sendTyped[T](ctx, c, http.MethodGet, path, nil, opts)
+}
+
+// Delete 发送DELETE请求并解析响应为T
+func Delete[T any](ctx context.Context, c *Client, path string, opts ...RequestOption) (T, *ResponseMeta, error) {
+	return sendTyped[T](ctx, c, http.MethodDelete, path, nil, opts)
+}
+
+// Post 将body编码后发送POST请求，并解析响应为Resp
+func Post[Req, Resp any](ctx context.Context, c *Client, path string, body Req, opts ...RequestOption) (Resp, *ResponseMeta, error) {
+	return sendTyped[Resp](ctx, c, http.MethodPost, path, body, opts)
+}
+
+// Put 将body编码后发送PUT请求，并解析响应为Resp
+func Put[Req, Resp any](ctx context.Context, c *Client, path string, body Req, opts ...RequestOption) (Resp, *ResponseMeta, error) {
+	return sendTyped[Resp](ctx, c, http.MethodPut, path, body, opts)
+}
+
+// Patch 将body编码后发送PATCH请求，并解析响应为Resp
+func Patch[Req, Resp any](ctx context.Context, c *Client, path string, body Req, opts ...RequestOption) (Resp, *ResponseMeta, error) {
+	return sendTyped[Resp](ctx, c, http.MethodPatch, path, body, opts)
+}
+
+func sendTyped[T any](ctx context.Context, c *Client, method, path string, body interface{}, opts []RequestOption) (T, *ResponseMeta, error) {
+	var (
+		out  T
+		meta = &ResponseMeta{}
+		o    = newRequestOptions(opts)
+	)
+	if o.err != nil {
+		return out, meta, o.err
+	}
+	if len(o.pathParams) > 0 {
+		var err error
+		if path, err = ExpandPath(path, o.pathParams); err != nil {
+			return out, meta, err
+		}
+	}
+
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	if o.success != nil {
+		ctx = ContextWithSuccess(ctx, o.success)
+	}
+	if o.codec != nil {
+		ctx = ContextWithCodec(ctx, o.codec)
+	}
+	ctx = ContextWithMeta(ctx, meta)
+
+	req, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return out, meta, err
+	}
+	o.applyRequest(req)
+
+	if err = c.SendRequest(req, &out); err != nil {
+		var zero T
+		return zero, meta, err
+	}
+
+	return out, meta, nil
+}
@@ -0,0 +1,86 @@
+package ghttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGeneric(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace", r.Header.Get("X-Trace"))
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Query().Get("expand") != "profile" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(user{ID: 1, Name: "alice"})
+		case http.MethodPost:
+			var u user
+			_ = json.NewDecoder(r.Body).Decode(&u)
+			u.ID = 2
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(u)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodPut:
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+	ctx := context.Background()
+
+	u, meta, err := Get[user](ctx, c, "/users/1", WithQuery("expand", "profile"), WithHeader("X-Trace", "t1"))
+	if err != nil || u.Name != "alice" {
+		t.Fatalf("Get = %+v, %v", u, err)
+	}
+	if meta.StatusCode != http.StatusOK || meta.Header.Get("X-Trace") != "t1" || meta.Attempts != 1 {
+		t.Fatalf("meta = %+v", meta)
+	}
+
+	created, meta, err := Post[user, *user](ctx, c, "/users", user{Name: "bob"})
+	if err != nil || created.ID != 2 || created.Name != "bob" || meta.StatusCode != http.StatusCreated {
+		t.Fatalf("Post = %+v, %+v, %v", created, meta, err)
+	}
+
+	if _, meta, err = Delete[struct{}](ctx, c, "/users/2"); err != nil || meta.StatusCode != http.StatusNoContent {
+		t.Fatalf("Delete = %+v, %v", meta, err)
+	}
+
+	_, _, err = Put[user, user](ctx, c, "/users/2", user{}, WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put err = %v, want deadline exceeded", err)
+	}
+
+	_, meta, err = Get[user](ctx, c, "/users/1")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || meta.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Get without query: err = %v, meta = %+v", err, meta)
+	}
+}
+
+func TestRequestQueryOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(r.URL.RawQuery)
+	}))
+	defer ts.Close()
+
+	// 原有的查询参数保持顺序和编码，新参数追加在后面
+	c := NewClient(WithBaseURL(ts.URL + "?z=1"))
+	query, _, err := Get[string](context.Background(), c, "/search?q=a%2Fb&b=2", WithQuery("a", "x y"))
+	if err != nil || query != "z=1&q=a%2Fb&b=2&a=x+y" {
+		t.Fatalf("query = %q, err = %v", query, err)
+	}
+}
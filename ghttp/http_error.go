@@ -0,0 +1,92 @@
+package ghttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
+
+// 错误中保留的响应体最大长度
+const maxErrorBodySize = 4096
+
+// 确保我们始终实现 gerror.CodeError
+var _ gerror.CodeError = (*HTTPError)(nil)
+
+// 默认的状态码与错误码对应关系，未包含的状态码使用gerror.CodeCommon
+var defaultErrorCodes = map[int]gerror.Code{
+	http.StatusUnauthorized: gerror.CodeUnauthorized,
+	http.StatusForbidden:    gerror.CodeUnauthorized,
+	http.StatusNotFound:     gerror.CodeNotExist,
+}
+
+// HTTPError 响应状态码不符合预期时返回的错误，可以通过errors.As获取
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte        // 响应体，最多保留4KB
+	Duration   time.Duration // 包括重试在内的总耗时
+	Attempts   int
+	ErrorBody  interface{} // 通过WithErrorBody设置时，从响应体解析出的错误信息
+	code       gerror.Code
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http error, method=%v, url=%v, statusCode=%v, err=%v", e.Method, e.URL, e.StatusCode, string(e.Body))
+}
+
+func (e *HTTPError) Code() gerror.Code { return e.code }
+
+// Unwrap 返回错误码对应的gerror预定义错误，例如404时可以通过errors.Is(err, gerror.DataNotExistError)判断；
+// 没有对应的预定义错误时返回nil
+func (e *HTTPError) Unwrap() error {
+	switch e.code {
+	case gerror.CodeCommon:
+		return gerror.CommonError
+	case gerror.CodeUnauthorized:
+		return gerror.UnauthorizedError
+	case gerror.CodeNotExist:
+		return gerror.DataNotExistError
+	case gerror.CodeUnavailable:
+		return gerror.UnavailableError
+	}
+	return nil
+}
+
+func (c *Client) httpCodeError(req *http.Request, res *http.Response, meta *ResponseMeta, body []byte) error {
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	e := &HTTPError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+		Duration:   meta.Duration,
+		Attempts:   meta.Attempts,
+		code:       c.errorCode(res.StatusCode),
+	}
+	if c.config.ErrorBody != nil && len(body) > 0 {
+		v := c.config.ErrorBody()
+		codec := c.responseCodec(req.Context(), res.Header.Get("Content-Type"))
+		if err := codec.Unmarshal(body, v); err == nil {
+			e.ErrorBody = v
+		}
+	}
+
+	return e
+}
+
+func (c *Client) errorCode(statusCode int) gerror.Code {
+	if code, ok := c.config.ErrorCodes[statusCode]; ok {
+		return code
+	}
+	if code, ok := defaultErrorCodes[statusCode]; ok {
+		return code
+	}
+	return gerror.CodeCommon
+}
@@ -0,0 +1,67 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestHTTPError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":1004,"message":"user not found"}`))
+		default:
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte("conflict"))
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithErrorCode(http.StatusConflict, 40009),
+		WithErrorBody(func() interface{} { return &apiError{} }),
+	)
+
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/missing", nil)
+	err := c.SendRequest(req, nil)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err = %T, want *HTTPError", err)
+	}
+	if httpErr.Method != http.MethodGet || httpErr.StatusCode != http.StatusNotFound || httpErr.URL != ts.URL+"/missing" {
+		t.Fatalf("unexpected error fields: %+v", httpErr)
+	}
+	if httpErr.Header.Get("X-Request-Id") != "abc" || httpErr.Attempts != 1 {
+		t.Fatalf("header or attempts not recorded: %+v", httpErr)
+	}
+	if !gerror.IsWithCode(gerror.CodeNotExist, err) || !errors.Is(err, gerror.DataNotExistError) {
+		t.Fatalf("code = %v, want %v", gerror.GetWithCode(err), gerror.CodeNotExist)
+	}
+	body, ok := httpErr.ErrorBody.(*apiError)
+	if !ok || body.Code != 1004 || body.Message != "user not found" {
+		t.Fatalf("error body = %#v", httpErr.ErrorBody)
+	}
+
+	req, _ = c.NewRequest(context.Background(), http.MethodGet, "/conflict", nil)
+	_, err = c.Request(req)
+	if gerror.GetWithCode(err) != 40009 || errors.Unwrap(err) != nil {
+		t.Fatalf("code = %v, want 40009", gerror.GetWithCode(err))
+	}
+	if errors.As(err, &httpErr); httpErr.ErrorBody != nil || string(httpErr.Body) != "conflict" {
+		t.Fatalf("non-JSON body: ErrorBody = %#v, Body = %q", httpErr.ErrorBody, httpErr.Body)
+	}
+}
@@ -0,0 +1,385 @@
+package ghttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	netUrl "net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+const (
+	defaultLogBodySize = 4096
+	redacted           = "***"
+)
+
+var (
+	defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	defaultRedactFields  = []string{"password", "passwd", "secret", "token", "access_token", "refresh_token", "client_secret", "api_key"}
+)
+
+// LoggingOption 请求日志的配置
+type LoggingOption interface {
+	apply(*logging)
+}
+
+type loggingOptionFunc func(*logging)
+
+func (f loggingOptionFunc) apply(l *logging) {
+	f(l)
+}
+
+// WithLogBodySize debug日志中请求体和响应体的最大长度，默认为4KB
+func WithLogBodySize(size int) LoggingOption {
+	return loggingOptionFunc(func(l *logging) {
+		l.maxBodySize = size
+	})
+}
+
+// WithRedactHeaders 额外需要隐藏的请求头和响应头
+func WithRedactHeaders(headers ...string) LoggingOption {
+	return loggingOptionFunc(func(l *logging) {
+		for _, h := range headers {
+			l.redactHeaders[http.CanonicalHeaderKey(h)] = true
+		}
+	})
+}
+
+// WithRedactFields 额外需要隐藏的JSON字段、表单字段和查询参数，不区分大小写
+func WithRedactFields(fields ...string) LoggingOption {
+	return loggingOptionFunc(func(l *logging) {
+		for _, f := range fields {
+			l.redactFields[strings.ToLower(f)] = true
+		}
+	})
+}
+
+type logging struct {
+	log           *glog.Log
+	maxBodySize   int
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+	fieldPattern  *regexp.Regexp
+}
+
+// 流式响应的Content-Type，读取响应体会阻塞到流结束
+var streamingTypes = map[string]bool{
+	"text/event-stream":       true,
+	"application/x-ndjson":    true,
+	"application/jsonl":       true,
+	"application/stream+json": true,
+}
+
+// Logging 使用glog记录请求日志，info级别记录方法、地址、状态码、耗时和大小，
+// debug级别另外记录一条包含请求头、响应头和请求体、响应体的日志
+func Logging(log *glog.Log, opts ...LoggingOption) Middleware {
+	l := newLogging(log, opts...)
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return l.do(next, req)
+		})
+	}
+}
+
+func newLogging(log *glog.Log, opts ...LoggingOption) *logging {
+	l := &logging{
+		log:           log,
+		maxBodySize:   defaultLogBodySize,
+		redactHeaders: make(map[string]bool),
+		redactFields:  make(map[string]bool),
+	}
+	WithRedactHeaders(defaultRedactHeaders...).apply(l)
+	WithRedactFields(defaultRedactFields...).apply(l)
+	for _, opt := range opts {
+		opt.apply(l)
+	}
+
+	fields := make([]string, 0, len(l.redactFields))
+	for f := range l.redactFields {
+		fields = append(fields, regexp.QuoteMeta(f))
+	}
+	// 无法解析的JSON(例如被截断)按字段名查找需要替换的值
+	l.fieldPattern = regexp.MustCompile(`(?i)"(?:` + strings.Join(fields, "|") + `)"\s*:\s*`)
+
+	return l
+}
+
+// WithLogging 添加Logging中间件
+func WithLogging(log *glog.Log, opts ...LoggingOption) Option {
+	return WithMiddleware(Logging(log, opts...))
+}
+
+func (l *logging) do(next Doer, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	debug := l.log.IsLevelEnabledContext(ctx, glog.DebugLevel)
+
+	var reqBody []byte
+	if debug {
+		req, reqBody = l.peekRequest(req)
+	}
+
+	start := time.Now()
+	res, err := next.Do(req)
+	latency := time.Since(start)
+
+	fields := []glog.Field{
+		{Key: "method", Value: req.Method},
+		{Key: "url", Value: l.redactURL(req.URL)},
+		{Key: "latency", Value: latency.String()},
+		{Key: "req_size", Value: req.ContentLength},
+	}
+	entry := l.log.WithContext(ctx)
+	if err != nil {
+		entry.WithFields(fields).WithError(err).Error("http request failed")
+	} else {
+		fields = append(fields,
+			glog.Field{Key: "status", Value: res.StatusCode},
+			glog.Field{Key: "resp_size", Value: res.ContentLength},
+		)
+		entry.WithFields(fields).Info("http request")
+	}
+	if !debug {
+		return res, err
+	}
+
+	// 请求头和请求体、响应头和响应体单独记录一条debug日志，不影响info级别的输出
+	details := []glog.Field{
+		{Key: "method", Value: req.Method},
+		{Key: "url", Value: l.redactURL(req.URL)},
+		{Key: "req_header", Value: l.redactHeader(req.Header)},
+		{Key: "req_body", Value: l.redactBody(req.Header.Get("Content-Type"), reqBody)},
+	}
+	if err == nil {
+		var resBody []byte
+		res, resBody = l.peekResponse(res)
+		details = append(details,
+			glog.Field{Key: "resp_header", Value: l.redactHeader(res.Header)},
+			glog.Field{Key: "resp_body", Value: l.redactBody(res.Header.Get("Content-Type"), resBody)},
+		)
+	}
+	entry.WithFields(details).Debug("http request detail")
+
+	return res, err
+}
+
+// 读取请求体的前maxBodySize字节，不影响发送的请求体
+func (l *logging) peekRequest(req *http.Request) (*http.Request, []byte) {
+	if req.Body == nil || req.Body == http.NoBody || !loggableBody(req.Header.Get("Content-Type")) {
+		return req, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return req, nil
+		}
+		defer body.Close()
+		b, _ := io.ReadAll(io.LimitReader(body, int64(l.maxBodySize)))
+		return req, b
+	}
+
+	b, body := peek(req.Body, l.maxBodySize)
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, b
+}
+
+// 读取响应体的前maxBodySize字节，调用方仍然可以读取完整的响应体
+func (l *logging) peekResponse(res *http.Response) (*http.Response, []byte) {
+	if res.Body == nil || res.Body == http.NoBody || !loggableBody(res.Header.Get("Content-Type")) {
+		return res, nil
+	}
+	b, body := peek(res.Body, l.maxBodySize)
+	res.Body = body
+	return res, b
+}
+
+// 只记录文本类型的body，跳过流式响应、文件和multipart等二进制内容
+func loggableBody(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || streamingTypes[mediaType] {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// 读取body的前n字节，返回的ReadCloser会重新读取这部分数据
+func peek(body io.ReadCloser, n int) ([]byte, io.ReadCloser) {
+	b, err := io.ReadAll(io.LimitReader(body, int64(n)))
+	reader := io.MultiReader(bytes.NewReader(b), body)
+	if err != nil {
+		reader = io.MultiReader(bytes.NewReader(b), &errReader{err: err})
+	}
+	return b, &replayBody{Reader: reader, Closer: body}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func (l *logging) redactHeader(header http.Header) http.Header {
+	h := make(http.Header, len(header))
+	for key, vals := range header {
+		if l.redactHeaders[http.CanonicalHeaderKey(key)] {
+			h[key] = []string{redacted}
+			continue
+		}
+		h[key] = vals
+	}
+	return h
+}
+
+// 只替换需要隐藏的参数值，保留其他参数原有的顺序和编码
+func (l *logging) redactURL(u *netUrl.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := netUrl.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if l.redactFields[strings.ToLower(key)] {
+			pairs[i] = rawKey + "=" + redacted
+		}
+	}
+	c := *u
+	c.RawQuery = strings.Join(pairs, "&")
+	return c.String()
+}
+
+func (l *logging) redactValues(values netUrl.Values) {
+	for key := range values {
+		if l.redactFields[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+		}
+	}
+}
+
+func (l *logging) redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := netUrl.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		l.redactValues(values)
+		return values.Encode()
+	case strings.HasSuffix(mediaType, "json") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return l.redactRawJSON(string(body))
+		}
+		b, err := json.Marshal(l.redactJSON(v))
+		if err != nil {
+			return string(body)
+		}
+		return string(b)
+	}
+	return string(body)
+}
+
+func (l *logging) redactJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if l.redactFields[strings.ToLower(key)] {
+				val[key] = redacted
+				continue
+			}
+			val[key] = l.redactJSON(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = l.redactJSON(item)
+		}
+	}
+	return v
+}
+
+// 替换无法解析的JSON中需要隐藏的字段值，值可以是任意类型，被截断的值替换到末尾
+func (l *logging) redactRawJSON(s string) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, loc := range l.fieldPattern.FindAllStringIndex(s, -1) {
+		if loc[0] < last {
+			continue
+		}
+		b.WriteString(s[last:loc[1]])
+		b.WriteString(`"` + redacted + `"`)
+		last = skipJSONValue(s, loc[1])
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// 返回从i开始的JSON值结束的位置
+func skipJSONValue(s string, i int) int {
+	var (
+		depth    int
+		inString bool
+	)
+	for ; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case ',', ' ', '\t', '\r', '\n':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
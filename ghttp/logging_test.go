@@ -0,0 +1,151 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+func newTestLog(buf *bytes.Buffer, level glog.Level) *glog.Log {
+	return &glog.Log{
+		Out:       buf,
+		Formatter: &glog.JSONFormatter{},
+		Level:     level,
+	}
+}
+
+func TestLogging(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `,"access_token":"tok-123"}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	c := NewClient(WithBaseURL(ts.URL), WithLogging(newTestLog(&buf, glog.DebugLevel), WithRedactFields("card")))
+
+	req, _ := c.NewRequest(context.Background(), http.MethodPost, "/login?token=q1&page=2",
+		map[string]interface{}{"user": "alice", "password": "p@ss", "card": "4111"})
+	req.Header.Set("Authorization", "Bearer secret")
+	var out struct {
+		Echo        map[string]string `json:"echo"`
+		AccessToken string            `json:"access_token"`
+	}
+	if err := c.SendRequest(req, &out); err != nil {
+		t.Fatal(err)
+	}
+	// 记录日志后调用方仍然可以读取完整的响应体
+	if out.AccessToken != "tok-123" || out.Echo["password"] != "p@ss" {
+		t.Fatalf("response consumed by logging: %+v", out)
+	}
+
+	line := buf.String()
+	for _, leak := range []string{"Bearer secret", "p@ss", "4111", "tok-123", "session=abc", "q1"} {
+		if strings.Contains(line, leak) {
+			t.Fatalf("log contains %q: %s", leak, line)
+		}
+	}
+	for _, want := range []string{`"method":"POST"`, `"status":200`, "alice", "page=2", `"_level":"debug"`} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("log missing %q: %s", want, line)
+		}
+	}
+	// debug级别仍然记录info级别的请求日志，请求体等记录在单独的debug日志中
+	lines := strings.Split(strings.TrimSpace(line), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"_level":"info"`) || strings.Contains(lines[0], "req_body") ||
+		!strings.Contains(lines[1], `"_level":"debug"`) || !strings.Contains(lines[1], "req_body") {
+		t.Fatalf("unexpected debug logs: %s", line)
+	}
+
+	// info级别不记录请求体和响应体
+	buf.Reset()
+	c = NewClient(WithBaseURL(ts.URL), WithLogging(newTestLog(&buf, glog.InfoLevel)))
+	req, _ = c.NewRequest(context.Background(), http.MethodGet, "/", nil)
+	if _, err := c.Request(req); err != nil {
+		t.Fatal(err)
+	}
+	if line = buf.String(); strings.Contains(line, "resp_body") || !strings.Contains(line, `"_level":"info"`) {
+		t.Fatalf("unexpected info log: %s", line)
+	}
+}
+
+func TestRedactTruncatedBody(t *testing.T) {
+	var buf bytes.Buffer
+	l := Logging(newTestLog(&buf, glog.DebugLevel), WithLogBodySize(24))
+	doer := l(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != `{"user":"bob","password":"very-long-secret"}` {
+			t.Fatalf("request body not replayed: %q", body)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com", io.NopCloser(strings.NewReader(`{"user":"bob","password":"very-long-secret"}`)))
+	if _, err := doer.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "very") {
+		t.Fatalf("truncated body not redacted: %s", buf.String())
+	}
+}
+
+func TestLoggingRedact(t *testing.T) {
+	l := newLogging(nil, WithRedactFields("pin"))
+
+	tests := []struct{ body, want string }{
+		{`{"user":"bob","pin":1234,"password":"very`, `{"user":"bob","pin":"***","password":"***"`},
+		{`{"token":{"a":[1,"}"]},"ok":true,"password":nul`, `{"token":"***","ok":true,"password":"***"`},
+	}
+	for _, tt := range tests {
+		if got := l.redactRawJSON(tt.body); got != tt.want {
+			t.Fatalf("redact %s = %s, want %s", tt.body, got, tt.want)
+		}
+	}
+
+	u, _ := url.Parse("http://example.com/a?z=1&Token=q%201&b=%2F&token")
+	if got := l.redactURL(u); got != "http://example.com/a?z=1&Token=***&b=%2F&token=***" {
+		t.Fatalf("redactURL = %s", got)
+	}
+}
+
+func TestLoggingStream(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	var buf bytes.Buffer
+	c := NewClient(WithBaseURL(ts.URL), WithLogging(newTestLog(&buf, glog.DebugLevel)))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := c.NewRequest(ctx, http.MethodGet, "/events", nil)
+
+	// debug级别不读取流式响应体，不会阻塞到流结束
+	res, err := c.Stream(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b := make([]byte, 9)
+	if _, err = io.ReadFull(res.Body, b); err != nil || string(b) != "data: 1\n\n" {
+		t.Fatalf("body = %q, err = %v", b, err)
+	}
+}
@@ -0,0 +1,27 @@
+package ghttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type metaKey struct{}
+
+// ResponseMeta 请求结束后的响应信息，通过ContextWithMeta传入请求的context获取
+type ResponseMeta struct {
+	Attempts   int           // 实际请求次数，包括重试
+	StatusCode int           // 最后一次请求的状态码，请求失败时为0
+	Header     http.Header   // 最后一次请求的响应头
+	Duration   time.Duration // 包括重试等待在内的总耗时
+}
+
+// ContextWithMeta 使用该context创建的请求发送后，会将响应信息写入meta
+func ContextWithMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+func metaFromContext(ctx context.Context) *ResponseMeta {
+	meta, _ := ctx.Value(metaKey{}).(*ResponseMeta)
+	return meta
+}
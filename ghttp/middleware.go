@@ -0,0 +1,99 @@
+package ghttp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const HeaderRequestID = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// Doer 发送请求，*http.Client实现了该接口
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware 包装Doer，在请求发送前后执行，重试时每次请求都会执行
+type Middleware func(next Doer) Doer
+
+// 第一个中间件在最外层
+func chain(doer Doer, middlewares []Middleware) Doer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		doer = middlewares[i](doer)
+	}
+	return doer
+}
+
+// DefaultHeaders 请求中没有设置的请求头使用header中的值
+func DefaultHeaders(header http.Header) Middleware {
+	header = header.Clone()
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for key, vals := range header {
+				if _, ok := req.Header[key]; !ok {
+					// 复制切片，后续对请求头的Add不会修改共享的值
+					req.Header[key] = append([]string(nil), vals...)
+				}
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// UserAgent 设置User-Agent请求头
+func UserAgent(userAgent string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", userAgent)
+			return next.Do(req)
+		})
+	}
+}
+
+// ContextWithRequestID 在context中保存请求ID，通过RequestID中间件传递给下游服务
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestID 将context中的请求ID设置到header请求头中，header为空时使用X-Request-Id
+func RequestID(header string) Middleware {
+	if header == "" {
+		header = HeaderRequestID
+	}
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if requestID := RequestIDFromContext(req.Context()); requestID != "" && req.Header.Get(header) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(header, requestID)
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// Timing 每次请求结束后调用fn，可以用于记录耗时等指标
+func Timing(fn func(req *http.Request, res *http.Response, err error, duration time.Duration)) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.Do(req)
+			fn(req, res, err, time.Since(start))
+			return res, err
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ghttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Join([]string{
+			r.Header.Get("User-Agent"),
+			r.Header.Get("X-Tenant"),
+			r.Header.Get(HeaderRequestID),
+			r.Header.Get("X-Order"),
+		}, "|")))
+	}))
+	defer ts.Close()
+
+	var (
+		order   []string
+		timings int
+	)
+	trace := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req = req.Clone(req.Context())
+				req.Header.Add("X-Order", name)
+				return next.Do(req)
+			})
+		}
+	}
+
+	c := NewClient(
+		WithBaseURL(ts.URL),
+		WithMiddleware(trace("outer"), trace("inner")),
+		WithMiddleware(
+			DefaultHeaders(http.Header{"X-Tenant": {"default"}}),
+			UserAgent("infrastructure/1.0"),
+			RequestID(""),
+			Timing(func(req *http.Request, res *http.Response, err error, d time.Duration) {
+				if err == nil && res.StatusCode == http.StatusOK && d > 0 {
+					timings++
+				}
+			}),
+		),
+	)
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	req, _ := c.NewRequest(ctx, http.MethodGet, "/", nil)
+	raw, err := c.SendRequestRaw(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Body != "infrastructure/1.0|default|req-1|outer" {
+		t.Fatalf("body = %q", raw.Body)
+	}
+	if strings.Join(order, ",") != "outer,inner" || timings != 1 {
+		t.Fatalf("order = %v, timings = %d", order, timings)
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Fatal("middleware must not modify the caller's request")
+	}
+
+	req, _ = c.NewRequest(context.Background(), http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant", "t2")
+	body, err := c.Request(req)
+	if err != nil || string(body) != "infrastructure/1.0|t2||outer" {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+}
+
+func TestDefaultHeadersCopy(t *testing.T) {
+	header := http.Header{"X-Tenant": make([]string, 1, 4)}
+	header["X-Tenant"][0] = "default"
+	doer := DefaultHeaders(header)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		req.Header.Add("X-Tenant", req.URL.Path)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/a", nil)
+			_, _ = doer.Do(req)
+		}()
+	}
+	wg.Wait()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/b", nil)
+	var got []string
+	doer = DefaultHeaders(header)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Values("X-Tenant")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	_, _ = doer.Do(req)
+	if len(got) != 1 || got[0] != "default" {
+		t.Fatalf("X-Tenant = %v", got)
+	}
+}
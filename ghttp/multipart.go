@@ -0,0 +1,292 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yueluoa/infrastructure/gutils/file"
+)
+
+const defaultContentType = "application/octet-stream"
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// Multipart multipart/form-data请求体，文件内容在发送时通过io.Pipe流式写入，不会整体读入内存
+type Multipart struct {
+	boundary string
+	parts    []multipartPart
+	progress func(written, total int64)
+	err      error
+}
+
+type multipartPart struct {
+	field       string
+	filename    string
+	contentType string
+	value       string                        // 文本字段
+	open        func() (io.ReadCloser, error) // 文件内容
+	size        int64                         // 文件大小，未知时为-1
+	reusable    bool                          // 是否可以重复读取，用于重试
+}
+
+func NewMultipart() *Multipart {
+	return &Multipart{boundary: randomBoundary()}
+}
+
+// AddField 添加文本字段
+func (m *Multipart) AddField(name, value string) *Multipart {
+	m.parts = append(m.parts, multipartPart{field: name, value: value, size: int64(len(value)), reusable: true})
+	return m
+}
+
+// AddFile 添加本地文件，根据扩展名确定Content-Type
+func (m *Multipart) AddFile(field, path string) *Multipart {
+	info, err := file.GetFileInfo(path)
+	if err != nil {
+		m.err = err
+		return m
+	}
+	if info.IsDir {
+		m.err = fmt.Errorf("ghttp: %s is a directory", path)
+		return m
+	}
+	contentType := info.MediaType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	m.parts = append(m.parts, multipartPart{
+		field:       field,
+		filename:    filepath.Base(path),
+		contentType: contentType,
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+		size:     info.Size,
+		reusable: true,
+	})
+	return m
+}
+
+// AddBytes 添加内存中的文件内容，contentType为空时根据内容检测
+func (m *Multipart) AddBytes(field, filename, contentType string, data []byte) *Multipart {
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	m.parts = append(m.parts, multipartPart{
+		field:       field,
+		filename:    filename,
+		contentType: contentType,
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+		size:     int64(len(data)),
+		reusable: true,
+	})
+	return m
+}
+
+// AddReader 添加从r读取的文件内容，size未知时传-1，此时请求不设置Content-Length；
+// r只能读取一次，包含该字段的请求不会重试
+func (m *Multipart) AddReader(field, filename, contentType string, r io.Reader, size int64) *Multipart {
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	m.parts = append(m.parts, multipartPart{
+		field:       field,
+		filename:    filename,
+		contentType: contentType,
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(r), nil
+		},
+		size: size,
+	})
+	return m
+}
+
+// OnProgress 上传过程中回调已写入的字节数，total未知时为-1
+func (m *Multipart) OnProgress(fn func(written, total int64)) *Multipart {
+	m.progress = fn
+	return m
+}
+
+func (m *Multipart) ContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+// ContentLength 返回请求体的总长度，存在未知大小的文件时返回-1
+func (m *Multipart) ContentLength() int64 {
+	counter := &countWriter{}
+	w := multipart.NewWriter(counter)
+	_ = w.SetBoundary(m.boundary)
+
+	var total int64
+	for _, part := range m.parts {
+		if part.size < 0 {
+			return -1
+		}
+		if _, err := w.CreatePart(part.header()); err != nil {
+			return -1
+		}
+		total += part.size
+	}
+	_ = w.Close()
+
+	return total + counter.n
+}
+
+func (m *Multipart) reusable() bool {
+	for _, part := range m.parts {
+		if !part.reusable {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader 返回流式生成的请求体，每次调用都会重新读取全部字段；
+// 第一次Read时才打开文件并启动写入的goroutine，未读取就Close不会启动
+func (m *Multipart) Reader() io.ReadCloser {
+	return &multipartBody{m: m}
+}
+
+type multipartBody struct {
+	m      *Multipart
+	mu     sync.Mutex
+	pr     *io.PipeReader
+	closed bool
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	if b.pr == nil {
+		b.pr = b.m.pipe()
+	}
+	pr := b.pr
+	b.mu.Unlock()
+
+	return pr.Read(p)
+}
+
+// Close 关闭管道，写入的goroutine随之退出并关闭已打开的文件
+func (b *multipartBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	if b.pr != nil {
+		return b.pr.Close()
+	}
+	return nil
+}
+
+func (m *Multipart) pipe() *io.PipeReader {
+	pr, pw := io.Pipe()
+	total := m.ContentLength()
+
+	go func() {
+		var out io.Writer = pw
+		if m.progress != nil {
+			out = &progressWriter{w: pw, total: total, fn: m.progress}
+		}
+		pw.CloseWithError(m.writeTo(out))
+	}()
+
+	return pr
+}
+
+func (m *Multipart) writeTo(out io.Writer) error {
+	w := multipart.NewWriter(out)
+	if err := w.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+	for _, part := range m.parts {
+		pw, err := w.CreatePart(part.header())
+		if err != nil {
+			return err
+		}
+		if part.open == nil {
+			if _, err = io.WriteString(pw, part.value); err != nil {
+				return err
+			}
+			continue
+		}
+		r, err := part.open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(pw, r)
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func (p *multipartPart) header() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.field))
+	if p.open != nil {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(p.filename))
+		h.Set("Content-Type", p.contentType)
+	}
+	h.Set("Content-Disposition", disposition)
+	return h
+}
+
+func randomBoundary() string {
+	var buf [30]byte
+	if _, err := io.ReadFull(rand.Reader, buf[:]); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", buf[:])
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+type progressWriter struct {
+	w       io.Writer
+	total   int64
+	written atomic.Int64
+	fn      func(written, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.fn(w.written.Add(int64(n)), w.total)
+	return n, err
+}
+
+// NewMultipartRequest 创建multipart/form-data请求，请求体在发送时流式生成
+func (c *Client) NewMultipartRequest(ctx context.Context, method string, url string, m *Multipart) (*http.Request, error) {
+	req, err := c.requestBuilder.multipartBuild(ctx, method, c.fullURL(url), m)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+
+	return req, nil
+}
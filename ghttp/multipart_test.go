@@ -0,0 +1,133 @@
+package ghttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMultipart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次请求返回503，验证重试时重新生成请求体
+		if count.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.ContentLength <= 0 {
+			t.Errorf("content length = %d", r.ContentLength)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse multipart: %v", err)
+			return
+		}
+		var parts []string
+		parts = append(parts, r.FormValue("title"))
+		for _, field := range []string{"report", "logo"} {
+			f, header, err := r.FormFile(field)
+			if err != nil {
+				t.Errorf("form file %s: %v", field, err)
+				return
+			}
+			b, _ := io.ReadAll(f)
+			parts = append(parts, header.Filename+":"+header.Header.Get("Content-Type")+":"+string(b))
+		}
+		_, _ = w.Write([]byte(strings.Join(parts, "|")))
+	}))
+	defer ts.Close()
+
+	var written, total int64
+	m := NewMultipart().
+		AddField("title", "monthly").
+		AddFile("report", path).
+		AddBytes("logo", "logo.png", "", []byte("\x89PNG\r\n\x1a\n")).
+		OnProgress(func(w, t int64) {
+			written, total = w, t
+		})
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetry(2, ConstantBackoff(0)))
+	req, err := c.NewMultipartRequest(context.Background(), http.MethodPut, "/upload", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := c.Request(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "monthly|report.json:application/json:{\"a\":1}|logo.png:image/png:\x89PNG\r\n\x1a\n"
+	if string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+	if total != m.ContentLength() || written != total {
+		t.Fatalf("progress = %d/%d, content length = %d", written, total, m.ContentLength())
+	}
+}
+
+func TestMultipartReader(t *testing.T) {
+	m := NewMultipart().AddReader("data", "data.bin", "", strings.NewReader("stream"), -1)
+	if m.ContentLength() != -1 || m.reusable() {
+		t.Fatal("reader part must have unknown length and not be reusable")
+	}
+	b, err := io.ReadAll(m.Reader())
+	if err != nil || !strings.Contains(string(b), "stream") || !strings.Contains(string(b), m.boundary+"--") {
+		t.Fatalf("body = %q, err = %v", b, err)
+	}
+
+	if _, err = NewClient().NewMultipartRequest(context.Background(), http.MethodPost, "/", NewMultipart().AddFile("f", "/not/exist")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestMultipartNotSent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+	c := NewClient()
+	for i := 0; i < 10; i++ {
+		req, err := c.NewMultipartRequest(context.Background(), http.MethodPost, "http://127.0.0.1/upload", NewMultipart().AddFile("report", path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			_ = req.Body.Close()
+		}
+		body, _ := req.GetBody()
+		_ = body.Close()
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines = %d, want %d", n, before)
+	}
+
+	// 读取一部分后关闭，写入的goroutine也会退出
+	body := NewMultipart().AddFile("report", path).Reader()
+	if _, err := body.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	_ = body.Close()
+	for i := 0; i < 1000 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines = %d after close, want %d", n, before)
+	}
+	if _, err := body.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected error reading closed body")
+	}
+}
@@ -0,0 +1,72 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+)
+
+// NDJSON每行的最大长度
+const maxNDJSONLineSize = 16 << 20
+
+// NDJSONStream 逐行读取换行分隔的JSON(application/x-ndjson)，空行会被跳过
+type NDJSONStream struct {
+	res     *http.Response
+	scanner *bufio.Scanner
+	codec   Codec
+	line    []byte
+	err     error
+}
+
+// 确保我们始终实现 Streamer
+var _ Streamer = (*NDJSONStream)(nil)
+
+// NDJSON 发送请求并返回逐行读取的响应，请求的context取消时结束
+func (c *Client) NDJSON(req *http.Request) (*NDJSONStream, error) {
+	// 不修改调用方的请求
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", "application/x-ndjson")
+	res, err := c.Stream(req)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineSize)
+
+	return &NDJSONStream{res: res, scanner: scanner, codec: c.itemCodec(req.Context())}, nil
+}
+
+// Codec 返回Decode解析每行使用的codec
+func (s *NDJSONStream) Codec() Codec {
+	return s.codec
+}
+
+func (s *NDJSONStream) Next() bool {
+	for s.scanner.Scan() {
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		s.line = line
+		return true
+	}
+	s.err = s.scanner.Err()
+	if ctxErr := s.res.Request.Context().Err(); ctxErr != nil {
+		s.err = ctxErr
+	}
+	return false
+}
+
+// Bytes 返回当前行，下次调用Next后失效
+func (s *NDJSONStream) Bytes() []byte {
+	return s.line
+}
+
+func (s *NDJSONStream) Err() error {
+	return s.err
+}
+
+func (s *NDJSONStream) Close() error {
+	return s.res.Body.Close()
+}
@@ -0,0 +1,168 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultExpiryDelta = 10 * time.Second
+	tokenTimeout       = 30 * time.Second
+)
+
+// 确保我们始终实现 Authenticator 和 Refresher
+var (
+	_ Authenticator = (*OAuth2ClientCredentials)(nil)
+	_ Refresher     = (*OAuth2ClientCredentials)(nil)
+)
+
+// OAuth2Config OAuth2客户端凭证模式的配置
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Params       map[string]string // 获取token时的额外参数，例如audience
+	AuthInParams bool              // 是否通过参数而不是Basic认证发送client_id和client_secret
+	ExpiryDelta  time.Duration     // 在过期前多久刷新token，默认为10秒
+	Client       *Client           // 请求token使用的Client，默认为NewClient()
+}
+
+// Token OAuth2 token接口的响应
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int64     `json:"expires_in"`
+	Expiry      time.Time `json:"-"`
+}
+
+// OAuth2ClientCredentials 使用客户端凭证模式获取并缓存token，并发请求只会获取一次token
+type OAuth2ClientCredentials struct {
+	config OAuth2Config
+	mu     sync.Mutex
+	token  *Token
+	call   *tokenCall
+}
+
+// 正在进行的token请求
+type tokenCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+func NewOAuth2ClientCredentials(config OAuth2Config) *OAuth2ClientCredentials {
+	if config.ExpiryDelta <= 0 {
+		config.ExpiryDelta = defaultExpiryDelta
+	}
+	if config.Client == nil {
+		config.Client = NewClient()
+	}
+	return &OAuth2ClientCredentials{config: config}
+}
+
+func (o *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := o.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+	return nil
+}
+
+// Refresh 丢弃请求使用的token，下次认证时重新获取
+func (o *OAuth2ClientCredentials) Refresh(req *http.Request) error {
+	_, used, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// 其他请求已经刷新过token
+	if o.token != nil && o.token.AccessToken == used {
+		o.token = nil
+	}
+	return nil
+}
+
+// Token 返回缓存的token，即将过期时重新获取
+func (o *OAuth2ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	o.mu.Lock()
+	if o.token != nil && (o.token.Expiry.IsZero() || time.Until(o.token.Expiry) > o.config.ExpiryDelta) {
+		token := o.token
+		o.mu.Unlock()
+		return token, nil
+	}
+	call := o.call
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		o.call = call
+		go o.fetch(call)
+	}
+	o.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (o *OAuth2ClientCredentials) fetch(call *tokenCall) {
+	// 不使用发起请求的context：等待同一个token的其他请求不受它的取消影响，
+	// 其中按请求设置的成功判断、编解码器和ResponseMeta也不能用于token接口
+	ctx, cancel := context.WithTimeout(context.Background(), tokenTimeout)
+	defer cancel()
+	call.token, call.err = o.requestToken(ctx)
+
+	o.mu.Lock()
+	if call.err == nil {
+		o.token = call.token
+	}
+	o.call = nil
+	o.mu.Unlock()
+
+	close(call.done)
+}
+
+func (o *OAuth2ClientCredentials) requestToken(ctx context.Context) (*Token, error) {
+	params := map[string]string{"grant_type": "client_credentials"}
+	if len(o.config.Scopes) > 0 {
+		params["scope"] = strings.Join(o.config.Scopes, " ")
+	}
+	for key, val := range o.config.Params {
+		params[key] = val
+	}
+	if o.config.AuthInParams {
+		params["client_id"] = o.config.ClientID
+		params["client_secret"] = o.config.ClientSecret
+	}
+
+	req, err := o.config.Client.NewEncodedRequest(ctx, http.MethodPost, o.config.TokenURL, params)
+	if err != nil {
+		return nil, err
+	}
+	if !o.config.AuthInParams {
+		req.SetBasicAuth(o.config.ClientID, o.config.ClientSecret)
+	}
+
+	token := &Token{}
+	if err = o.config.Client.SendRequest(req, token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("ghttp: oauth2 token response missing access_token")
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
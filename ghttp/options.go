@@ -1,5 +1,7 @@
 package ghttp
 
+import "github.com/yueluoa/infrastructure/gerror"
+
 type Option interface {
 	apply(*Client)
 }
@@ -23,3 +25,97 @@ func WithBaseURL(baseURL string) Option {
 		c.config.BaseURL = baseURL
 	})
 }
+
+// WithRetry 失败时最多请求maxAttempts次，其余配置使用DefaultRetryPolicy
+func WithRetry(maxAttempts int, backoff Backoff) Option {
+	return NewLogOption(func(c *Client) {
+		policy := DefaultRetryPolicy()
+		policy.MaxAttempts = maxAttempts
+		if backoff != nil {
+			policy.Backoff = backoff
+		}
+		c.config.Retry = policy
+	})
+}
+
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.Retry = policy
+	})
+}
+
+// WithErrorCode 指定HTTPError中状态码对应的错误码
+func WithErrorCode(statusCode int, code gerror.Code) Option {
+	return NewLogOption(func(c *Client) {
+		if c.config.ErrorCodes == nil {
+			c.config.ErrorCodes = make(map[int]gerror.Code)
+		}
+		c.config.ErrorCodes[statusCode] = code
+	})
+}
+
+// WithErrorBody 将JSON错误响应体解析到newBody返回的指针中，通过HTTPError.ErrorBody获取
+func WithErrorBody(newBody func() interface{}) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.ErrorBody = newBody
+	})
+}
+
+// WithSuccess 指定判断请求是否成功的方法，默认状态码为2xx即成功
+func WithSuccess(fn SuccessFunc) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.Success = fn
+	})
+}
+
+// WithStreamSuccess 指定判断流式响应是否成功的方法，只能根据状态码和响应头判断，默认状态码为2xx即成功
+func WithStreamSuccess(fn SuccessFunc) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.StreamSuccess = fn
+	})
+}
+
+// WithMiddleware 添加中间件，先添加的在外层
+func WithMiddleware(middlewares ...Middleware) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.Middlewares = append(c.config.Middlewares, middlewares...)
+	})
+}
+
+// WithMaxBodySize 限制SendRequest、SendRequestRaw和Request读取的响应体大小，超过时返回ErrBodyTooLarge
+func WithMaxBodySize(size int64) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.MaxBodySize = size
+	})
+}
+
+// WithCodec 使用codec编码请求体，并用于解码Content-Type为codec.ContentType()的响应，codec为nil时忽略
+func WithCodec(codec Codec) Option {
+	return NewLogOption(func(c *Client) {
+		if codec == nil {
+			return
+		}
+		c.config.Codec = codec
+		WithResponseCodec(codec.ContentType(), codec).apply(c)
+	})
+}
+
+// WithResponseCodec 使用codec解码Content-Type为contentType的响应，codec为nil时忽略
+func WithResponseCodec(contentType string, codec Codec) Option {
+	return NewLogOption(func(c *Client) {
+		if codec == nil {
+			return
+		}
+		if c.config.Codecs == nil {
+			c.config.Codecs = make(map[string]Codec)
+		}
+		c.config.Codecs[mediaType(contentType)] = codec
+	})
+}
+
+// WithAuthenticator 发送请求前设置认证信息，实现了Refresher时收到401会刷新认证信息并重试一次
+func WithAuthenticator(auth Authenticator) Option {
+	return NewLogOption(func(c *Client) {
+		c.config.Authenticator = auth
+	})
+}
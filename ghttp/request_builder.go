@@ -3,33 +3,31 @@ package ghttp
 import (
 	"bytes"
 	"context"
+	"io"
 	"net/http"
 	netUrl "net/url"
 	"strings"
 )
 
 type requestBuilder interface {
-	jsonBuild(ctx context.Context, method, url string, request interface{}) (*http.Request, error)
+	codecBuild(ctx context.Context, method, url string, codec Codec, request interface{}) (*http.Request, error)
 	encodedBuild(ctx context.Context, method, url string, params map[string]string) (*http.Request, error)
+	multipartBuild(ctx context.Context, method, url string, m *Multipart) (*http.Request, error)
 }
 
-type httpRequestBuilder struct {
-	marshaller marshaller
-}
+type httpRequestBuilder struct{}
 
 func newRequestBuilder() *httpRequestBuilder {
-	return &httpRequestBuilder{
-		marshaller: &jsonMarshaller{},
-	}
+	return &httpRequestBuilder{}
 }
 
-func (b *httpRequestBuilder) jsonBuild(ctx context.Context, method, url string, request interface{}) (*http.Request, error) {
+func (b *httpRequestBuilder) codecBuild(ctx context.Context, method, url string, codec Codec, request interface{}) (*http.Request, error) {
 	if request == nil {
 		return http.NewRequestWithContext(ctx, method, url, nil)
 	}
 
 	var reqBytes []byte
-	reqBytes, err := b.marshaller.marshal(request)
+	reqBytes, err := codec.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
@@ -59,3 +57,24 @@ func (b *httpRequestBuilder) encodedBuild(ctx context.Context, method, url strin
 		strings.NewReader(urlValues.Encode()),
 	)
 }
+
+func (b *httpRequestBuilder) multipartBuild(ctx context.Context, method, url string, m *Multipart) (*http.Request, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = m.Reader()
+	req.Header.Set("Content-Type", m.ContentType())
+	req.ContentLength = m.ContentLength()
+	if m.reusable() {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return m.Reader(), nil
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,115 @@
+package ghttp
+
+import (
+	"net/http"
+	netUrl "net/url"
+	"time"
+)
+
+// RequestOption 单个请求的配置，用于Get、Post等方法
+type RequestOption interface {
+	apply(*requestOptions)
+}
+
+type requestOptions struct {
+	header     http.Header
+	query      netUrl.Values
+	pathParams map[string]string
+	timeout    time.Duration
+	success    SuccessFunc
+	codec      Codec
+	err        error
+}
+
+type requestOptionFunc func(*requestOptions)
+
+func (f requestOptionFunc) apply(o *requestOptions) {
+	f(o)
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{
+		header:     make(http.Header),
+		query:      make(netUrl.Values),
+		pathParams: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// WithHeader 设置请求头
+func WithHeader(key, value string) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.header.Set(key, value)
+	})
+}
+
+// WithQuery 添加查询参数
+func WithQuery(key, value string) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.query.Add(key, value)
+	})
+}
+
+// WithQueryValues 添加多个查询参数
+func WithQueryValues(values netUrl.Values) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		for key, vals := range values {
+			for _, val := range vals {
+				o.query.Add(key, val)
+			}
+		}
+	})
+}
+
+// WithQueryParams 添加url.Values、map或struct中的查询参数，参考EncodeQuery
+func WithQueryParams(v interface{}) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		values, err := EncodeQuery(v)
+		if err != nil {
+			o.err = err
+			return
+		}
+		WithQueryValues(values).apply(o)
+	})
+}
+
+// WithPathParam 替换路径模板中的参数，例如 /users/{id}
+func WithPathParam(key, value string) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.pathParams[key] = value
+	})
+}
+
+// WithTimeout 请求的超时时间，包括重试
+func WithTimeout(timeout time.Duration) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.timeout = timeout
+	})
+}
+
+// WithRequestSuccess 判断该请求是否成功，优先于Client的配置
+func WithRequestSuccess(fn SuccessFunc) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.success = fn
+	})
+}
+
+// WithRequestCodec 该请求使用codec编码请求体和解码响应体
+func WithRequestCodec(codec Codec) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.codec = codec
+	})
+}
+
+func (o *requestOptions) applyRequest(req *http.Request) {
+	for key, vals := range o.header {
+		req.Header[key] = vals
+	}
+	// 只追加新的参数，保留原有查询参数的顺序和编码
+	if len(o.query) > 0 {
+		req.URL.RawQuery = appendForm(req.URL.RawQuery, o.query)
+	}
+}
@@ -0,0 +1,233 @@
+package ghttp
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const defaultMaxRetryAfter = time.Minute
+
+// Backoff 返回第attempt次重试(从1开始)前的等待时间
+type Backoff interface {
+	Backoff(attempt int) time.Duration
+}
+
+type BackoffFunc func(attempt int) time.Duration
+
+func (f BackoffFunc) Backoff(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ConstantBackoff 每次重试等待相同的时间
+func ConstantBackoff(d time.Duration) Backoff {
+	return BackoffFunc(func(int) time.Duration {
+		return d
+	})
+}
+
+// ExponentialBackoff 等待时间从base开始指数增长，最大为max
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		return exponential(base, max, attempt)
+	})
+}
+
+// JitterBackoff 在[0, 指数增长的等待时间)之间随机等待，避免大量客户端同时重试
+func JitterBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		d := exponential(base, max, attempt)
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d)))
+	})
+}
+
+func exponential(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max || d <= 0 {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// RetryPolicy 请求失败时的重试策略
+type RetryPolicy struct {
+	MaxAttempts        int                                      // 最大请求次数(包括第一次)，小于等于1时不重试
+	Backoff            Backoff                                  // 重试等待时间，默认为JitterBackoff(100ms, 5s)
+	StatusCodes        []int                                    // 需要重试的状态码，默认为429、502、503、504
+	RetryNonIdempotent bool                                     // 是否重试非幂等的请求(POST、PATCH)，默认不重试
+	IgnoreRetryAfter   bool                                     // 是否忽略响应的Retry-After头
+	MaxRetryAfter      time.Duration                            // Retry-After的最大等待时间，默认为1分钟
+	ShouldRetry        func(res *http.Response, err error) bool // 自定义是否重试，设置后替代状态码和网络错误的判断
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     JitterBackoff(100*time.Millisecond, 5*time.Second),
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		MaxRetryAfter: defaultMaxRetryAfter,
+	}
+}
+
+func (rp *RetryPolicy) retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if !rp.RetryNonIdempotent && !isIdempotent(req) {
+		return false
+	}
+	// 请求体无法重新读取
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if rp.ShouldRetry != nil {
+		return rp.ShouldRetry(res, err)
+	}
+	if err != nil {
+		return IsRetryableError(err)
+	}
+	for _, code := range rp.StatusCodes {
+		if res.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// 返回第attempt次重试前的等待时间，优先使用Retry-After
+func (rp *RetryPolicy) wait(attempt int, res *http.Response) time.Duration {
+	if res != nil && !rp.IgnoreRetryAfter {
+		if d, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			max := rp.MaxRetryAfter
+			if max <= 0 {
+				max = defaultMaxRetryAfter
+			}
+			if d > max {
+				d = max
+			}
+			return d
+		}
+	}
+	if rp.Backoff == nil {
+		return 0
+	}
+	return rp.Backoff.Backoff(attempt)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// 带有幂等键的请求也可以重试
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// IsRetryableError 判断是否为可以重试的网络错误，例如超时、连接被拒绝或重置
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// 证书错误重试也不会成功
+	var (
+		certErr x509.UnknownAuthorityError
+		hostErr x509.HostnameError
+		invalid x509.CertificateInvalidError
+	)
+	if errors.As(err, &certErr) || errors.As(err, &hostErr) || errors.As(err, &invalid) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial" || opErr.Op == "read"
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+
+	return false
+}
+
+// Retry-After 支持秒数和HTTP日期两种格式
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// 等待d后重试，ctx在等待结束前到期时不再重试
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// 丢弃并关闭需要重试的响应，使连接可以复用
+func discardResponse(res *http.Response) {
+	if res == nil || res.Body == nil {
+		return
+	}
+	_, _ = io.CopyN(io.Discard, res.Body, 4096)
+	_ = res.Body.Close()
+}
@@ -0,0 +1,165 @@
+package ghttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryStatus(t *testing.T) {
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"ok"}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetry(3, ConstantBackoff(time.Millisecond)))
+
+	var meta ResponseMeta
+	req, err := c.NewRequest(ContextWithMeta(context.Background(), &meta), http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Name string }
+	if err = c.SendRequest(req, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "ok" {
+		t.Fatalf("name = %q, want ok", v.Name)
+	}
+	if meta.Attempts != 3 || meta.StatusCode != http.StatusOK {
+		t.Fatalf("meta = %+v, want 3 attempts and status 200", meta)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetry(3, ConstantBackoff(0)))
+	req, _ := c.NewRequest(context.Background(), http.MethodPost, "/", map[string]string{"a": "b"})
+	if err := c.SendRequest(req, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := count.Load(); n != 1 {
+		t.Fatalf("POST sent %d times, want 1", n)
+	}
+
+	count.Store(0)
+	policy := DefaultRetryPolicy()
+	policy.Backoff = ConstantBackoff(0)
+	policy.RetryNonIdempotent = true
+	c = NewClient(WithBaseURL(ts.URL), WithRetryPolicy(policy))
+	req, _ = c.NewRequest(context.Background(), http.MethodPost, "/", map[string]string{"a": "b"})
+	_ = c.SendRequest(req, nil)
+	if n := count.Load(); n != 3 {
+		t.Fatalf("POST sent %d times, want 3", n)
+	}
+}
+
+func TestRetryRewindBody(t *testing.T) {
+	var (
+		count  atomic.Int32
+		bodies = make(chan string, 3)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+		if count.Add(1) < 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetry(3, ConstantBackoff(0)))
+	req, _ := c.NewRequest(context.Background(), http.MethodPut, "/", map[string]string{"a": "b"})
+	if _, err := c.Request(req); err != nil {
+		t.Fatal(err)
+	}
+	close(bodies)
+	for body := range bodies {
+		if body != `{"a":"b"}` {
+			t.Fatalf("body = %q, want the same body on every attempt", body)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var count atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithRetry(3, ConstantBackoff(0)))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var meta ResponseMeta
+	req, _ := c.NewRequest(ContextWithMeta(ctx, &meta), http.MethodGet, "/", nil)
+	start := time.Now()
+	err := c.SendRequest(req, nil)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("err = %v, want the 429 response", err)
+	}
+	// Retry-After超过ctx的截止时间，不等待直接返回
+	if time.Since(start) > 500*time.Millisecond || meta.Attempts != 1 {
+		t.Fatalf("waited %v with %d attempts, want immediate return", time.Since(start), meta.Attempts)
+	}
+
+	if d, ok := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || d < 59*time.Minute {
+		t.Fatalf("parseRetryAfter(http date) = %v, %v", d, ok)
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	c := NewClient(WithBaseURL(url), WithRetry(3, ConstantBackoff(0)))
+	var meta ResponseMeta
+	req, _ := c.NewRequest(ContextWithMeta(context.Background(), &meta), http.MethodGet, "/", nil)
+	if err := c.SendRequest(req, nil); err == nil {
+		t.Fatal("expected error")
+	} else if !IsRetryableError(err) {
+		t.Fatalf("IsRetryableError(%v) = false", err)
+	}
+	if meta.Attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", meta.Attempts)
+	}
+	if IsRetryableError(context.Canceled) {
+		t.Fatal("context.Canceled should not be retried")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	exp := ExponentialBackoff(100*time.Millisecond, time.Second)
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if d := exp.Backoff(i + 1); d != w {
+			t.Fatalf("exponential attempt %d = %v, want %v", i+1, d, w)
+		}
+	}
+	jitter := JitterBackoff(100*time.Millisecond, time.Second)
+	for i := 1; i < 10; i++ {
+		if d := jitter.Backoff(i); d < 0 || d >= time.Second {
+			t.Fatalf("jitter attempt %d = %v out of range", i, d)
+		}
+	}
+}
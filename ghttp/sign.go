@@ -0,0 +1,349 @@
+package ghttp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	netUrl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignAlgorithm 根据待签名字符串和密钥计算签名
+type SignAlgorithm func(message, secret string) string
+
+// SignMD5 与gutils.MD5一致，对待签名字符串拼接密钥后计算MD5
+func SignMD5(message, secret string) string {
+	return md5Hex(message + secret)
+}
+
+// SignMD5WithKey 对 "待签名字符串&keyName=密钥" 计算MD5并转换成大写，常见于微信支付等接口
+func SignMD5WithKey(keyName string) SignAlgorithm {
+	return func(message, secret string) string {
+		return strings.ToUpper(md5Hex(message + "&" + keyName + "=" + secret))
+	}
+}
+
+// SignHMACSHA256 计算HMAC-SHA256，结果为小写十六进制
+func SignHMACSHA256(message, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func md5Hex(v string) string {
+	m := md5.New()
+	m.Write([]byte(v))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+type SignPlacement int
+
+const (
+	SignInHeader SignPlacement = iota // 时间戳、随机数和签名放在请求头中
+	SignInParams                      // 放在参数中，表单请求放在请求体中，其他请求放在查询参数中
+)
+
+// SignConfig 请求签名的配置，客户端的Sign和服务端的Verifier需要使用相同的配置
+type SignConfig struct {
+	Secret    string
+	Algorithm SignAlgorithm // 默认为SignHMACSHA256
+	Placement SignPlacement
+
+	SignatureKey string // 签名的参数名，默认为sign
+	TimestampKey string // 秒级时间戳的参数名，默认为timestamp
+	NonceKey     string // 随机数的参数名，默认为nonce
+
+	SignatureHeader string // Placement为SignInHeader时签名的请求头，默认为X-Signature
+	TimestampHeader string // 默认为X-Timestamp
+	NonceHeader     string // 默认为X-Nonce
+
+	KeyValueSep  string   // 参数名和值之间的分隔符，默认为=
+	PairSep      string   // 参数之间的分隔符，默认为&
+	IncludeEmpty bool     // 是否签名空值参数，默认不签名
+	Exclude      []string // 不参与签名的参数
+
+	// BodyDigestKey 请求体不是表单或JSON对象时(例如XML、JSON数组、multipart和二进制内容)，
+	// 以该参数名将请求体的SHA256参与签名，该参数不会发送，默认为body_sha256
+	BodyDigestKey string
+	// MaxBodySize Verifier读取请求体的最大长度，默认为10MB
+	MaxBodySize int64
+}
+
+func (cfg SignConfig) withDefaults() SignConfig {
+	setDefault := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	if cfg.Algorithm == nil {
+		cfg.Algorithm = SignHMACSHA256
+	}
+	setDefault(&cfg.SignatureKey, "sign")
+	setDefault(&cfg.TimestampKey, "timestamp")
+	setDefault(&cfg.NonceKey, "nonce")
+	setDefault(&cfg.SignatureHeader, "X-Signature")
+	setDefault(&cfg.TimestampHeader, "X-Timestamp")
+	setDefault(&cfg.NonceHeader, "X-Nonce")
+	setDefault(&cfg.KeyValueSep, "=")
+	setDefault(&cfg.PairSep, "&")
+	setDefault(&cfg.BodyDigestKey, "body_sha256")
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = defaultSignMaxBodySize
+	}
+	return cfg
+}
+
+// Canonical 生成待签名字符串：排除签名和指定的参数，默认排除空值，按参数名排序后拼接
+func (cfg SignConfig) Canonical(params netUrl.Values) string {
+	cfg = cfg.withDefaults()
+	exclude := make(map[string]bool, len(cfg.Exclude)+1)
+	exclude[cfg.SignatureKey] = true
+	for _, key := range cfg.Exclude {
+		exclude[key] = true
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if !exclude[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		for _, val := range params[key] {
+			if val == "" && !cfg.IncludeEmpty {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteString(cfg.PairSep)
+			}
+			b.WriteString(key)
+			b.WriteString(cfg.KeyValueSep)
+			b.WriteString(val)
+		}
+	}
+	return b.String()
+}
+
+// Signature 计算参数的签名
+func (cfg SignConfig) Signature(params netUrl.Values) string {
+	cfg = cfg.withDefaults()
+	return cfg.Algorithm(cfg.Canonical(params), cfg.Secret)
+}
+
+// ErrSignBodyNotReplayable 请求体不是表单或JSON，并且没有GetBody，无法在不读取请求体的情况下计算SHA256
+var ErrSignBodyNotReplayable = errors.New("ghttp: sign request body without GetBody")
+
+// Sign 签名中间件，每次请求(包括重试)都会生成新的时间戳和随机数；
+// 表单和JSON请求体读取到内存中签名，其他请求体(例如multipart上传的文件)通过GetBody流式计算SHA256，
+// 不会缓存请求体，没有GetBody时返回ErrSignBodyNotReplayable
+func Sign(config SignConfig) Middleware {
+	cfg := config.withDefaults()
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req, body, params, err := cfg.requestParams(req)
+			if err != nil {
+				return nil, err
+			}
+
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			nonce := newNonce()
+			params.Set(cfg.TimestampKey, timestamp)
+			params.Set(cfg.NonceKey, nonce)
+			signature := cfg.Signature(params)
+
+			if cfg.Placement == SignInHeader {
+				req.Header.Set(cfg.TimestampHeader, timestamp)
+				req.Header.Set(cfg.NonceHeader, nonce)
+				req.Header.Set(cfg.SignatureHeader, signature)
+				return next.Do(req)
+			}
+
+			extra := netUrl.Values{}
+			extra.Set(cfg.TimestampKey, timestamp)
+			extra.Set(cfg.NonceKey, nonce)
+			extra.Set(cfg.SignatureKey, signature)
+			if isForm(req.Header.Get("Content-Type")) {
+				setRequestBody(req, []byte(appendForm(string(body), extra)))
+			} else {
+				u := *req.URL
+				u.RawQuery = appendForm(u.RawQuery, extra)
+				req.URL = &u
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// 返回可以修改的请求副本和参与签名的参数，只有表单和JSON请求体会读取到内存中
+func (cfg SignConfig) requestParams(req *http.Request) (*http.Request, []byte, netUrl.Values, error) {
+	contentType := req.Header.Get("Content-Type")
+	if paramsInBody(contentType) {
+		r, body, err := readRequestBody(req)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		params, err := cfg.signParams(r.URL.Query(), contentType, body)
+		return r, body, params, err
+	}
+
+	r := req.Clone(req.Context())
+	params, _ := cfg.signParams(r.URL.Query(), contentType, nil)
+	digest, err := hashRequestBody(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if digest != "" {
+		params.Set(cfg.BodyDigestKey, digest)
+	}
+	return r, nil, params, nil
+}
+
+// 通过GetBody流式计算请求体的SHA256，请求体为空时返回空字符串
+func hashRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	if req.GetBody == nil {
+		return "", ErrSignBodyNotReplayable
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, body)
+	if err != nil || n == 0 {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 读取请求体并返回可以修改的请求副本，请求体可以再次读取
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil, nil
+	}
+
+	var (
+		body io.ReadCloser = req.Body
+		err  error
+	)
+	if req.GetBody != nil {
+		if body, err = req.GetBody(); err != nil {
+			return nil, nil, err
+		}
+	}
+	b, err := io.ReadAll(body)
+	_ = body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	setRequestBody(r, b)
+
+	return r, b, nil
+}
+
+func setRequestBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+func appendForm(form string, values netUrl.Values) string {
+	if form == "" {
+		return values.Encode()
+	}
+	return form + "&" + values.Encode()
+}
+
+func isForm(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == ContentTypeForm
+}
+
+func paramsInBody(contentType string) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	return mt == ContentTypeForm || mt == ContentTypeJSON || strings.HasSuffix(mt, "+json")
+}
+
+// 合并查询参数和请求体中的参数，支持表单和JSON对象(只取第一层，嵌套的值使用紧凑的JSON)，
+// 其他不为空的请求体使用SHA256参与签名，防止未签名的请求体被篡改
+func (cfg SignConfig) signParams(query netUrl.Values, contentType string, body []byte) (netUrl.Values, error) {
+	params := netUrl.Values{}
+	for key, vals := range query {
+		params[key] = append(params[key], vals...)
+	}
+	if len(body) == 0 {
+		return params, nil
+	}
+
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case !paramsInBody(contentType):
+	case len(bytes.TrimSpace(body)) == 0:
+		return params, nil
+	case mt == ContentTypeForm:
+		form, err := netUrl.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for key, vals := range form {
+			params[key] = append(params[key], vals...)
+		}
+		return params, nil
+	default:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err == nil {
+			for key, raw := range fields {
+				params.Add(key, jsonParamValue(raw))
+			}
+			return params, nil
+		}
+	}
+
+	digest := sha256.Sum256(body)
+	params.Set(cfg.BodyDigestKey, hex.EncodeToString(digest[:]))
+	return params, nil
+}
+
+func jsonParamValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	if bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+func newNonce() string {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,211 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	netUrl "net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignCanonical(t *testing.T) {
+	cfg := SignConfig{Secret: "s", KeyValueSep: ":", PairSep: ";", Exclude: []string{"sign_type"}}
+	params := netUrl.Values{
+		"b":         {"2"},
+		"a":         {"1"},
+		"empty":     {""},
+		"sign":      {"x"},
+		"sign_type": {"MD5"},
+	}
+	if got := cfg.Canonical(params); got != "a:1;b:2" {
+		t.Fatalf("Canonical = %q", got)
+	}
+	cfg.IncludeEmpty = true
+	if got := cfg.Canonical(params); got != "a:1;b:2;empty:" {
+		t.Fatalf("Canonical with empty = %q", got)
+	}
+
+	// 与gutils.MD5("a=1key")一致
+	if got := SignMD5("a=1", "key"); got != "033225d7ad9c4ca917bad4f75f322790" {
+		t.Fatalf("SignMD5 = %q", got)
+	}
+	if got := SignMD5WithKey("key")("a=1", "secret"); got != "8DBB43445EDB78BD6A55900584213E82" {
+		t.Fatalf("SignMD5WithKey = %q", got)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	configs := map[string]SignConfig{
+		"header": {Secret: "secret"},
+		"params": {Secret: "secret", Algorithm: SignMD5WithKey("key"), Placement: SignInParams},
+	}
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			verifier := NewVerifier(cfg, time.Minute, nil)
+			ts := httptest.NewServer(verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// 校验后仍然可以读取请求体
+				body, _ := io.ReadAll(r.Body)
+				_, _ = w.Write(body)
+			})))
+			defer ts.Close()
+
+			c := NewClient(WithBaseURL(ts.URL), WithMiddleware(Sign(cfg)))
+			ctx := context.Background()
+
+			req, _ := c.NewRequest(ctx, http.MethodPost, "/pay?channel=wx", map[string]interface{}{"amount": 100, "memo": "", "items": []int{1, 2}})
+			body, err := c.Request(req)
+			if err != nil || string(body) != `{"amount":100,"items":[1,2],"memo":""}` {
+				t.Fatalf("json: body = %q, err = %v", body, err)
+			}
+
+			req, _ = c.NewEncodedRequest(ctx, http.MethodPost, "/sms", map[string]string{"phone": "13800000000", "text": "验证码 1234"})
+			if _, err = c.Request(req); err != nil {
+				t.Fatalf("form: %v", err)
+			}
+
+			req, _ = c.NewRequest(ctx, http.MethodGet, "/query?order_id=1&empty=", nil)
+			if _, err = c.Request(req); err != nil {
+				t.Fatalf("query: %v", err)
+			}
+
+			// 签名密钥错误
+			bad := NewClient(WithBaseURL(ts.URL), WithMiddleware(Sign(SignConfig{Secret: "wrong", Algorithm: cfg.Algorithm, Placement: cfg.Placement})))
+			req, _ = bad.NewRequest(ctx, http.MethodGet, "/query", nil)
+			var httpErr *HTTPError
+			if _, err = bad.Request(req); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("wrong secret: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignReplay(t *testing.T) {
+	cfg := SignConfig{Secret: "secret"}
+	verifier := NewVerifier(cfg, time.Minute, nil)
+
+	// 记录签名后的请求
+	var signed *http.Request
+	doer := Sign(cfg)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		signed = req
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/orders?id=1", nil)
+	if _, err := doer.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifier.Verify(signed.Clone(context.Background())); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := verifier.Verify(signed.Clone(context.Background())); !errors.Is(err, ErrNonceReplayed) {
+		t.Fatalf("replayed request: err = %v, want ErrNonceReplayed", err)
+	}
+
+	// 时间戳超出允许的范围
+	old := signed.Clone(context.Background())
+	timestamp := strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10)
+	old.Header.Set("X-Timestamp", timestamp)
+	old.Header.Set("X-Nonce", "n2")
+	params := netUrl.Values{"id": {"1"}, "timestamp": {timestamp}, "nonce": {"n2"}}
+	old.Header.Set("X-Signature", cfg.Signature(params))
+	if err := verifier.Verify(old); !errors.Is(err, ErrSignatureExpired) {
+		t.Fatalf("expired request: err = %v, want ErrSignatureExpired", err)
+	}
+
+	missing, _ := http.NewRequest(http.MethodGet, "http://example.com/orders", nil)
+	if err := verifier.Verify(missing); !errors.Is(err, ErrSignatureMissing) {
+		t.Fatalf("unsigned request: err = %v, want ErrSignatureMissing", err)
+	}
+}
+
+func TestSignBody(t *testing.T) {
+	cfg := SignConfig{Secret: "secret", MaxBodySize: 64}
+	verifier := NewVerifier(cfg, time.Minute, nil)
+
+	var signed *http.Request
+	doer := Sign(cfg)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		signed = req
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	sign := func(contentType, body string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "http://example.com/orders", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if _, err := doer.Do(req); err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	// 不是表单或JSON对象的请求体通过SHA256签名，被篡改后校验失败
+	for contentType, body := range map[string]string{
+		ContentTypeXML:             "<order><amount>100</amount></order>",
+		ContentTypeJSON:            "[1,2]",
+		"application/octet-stream": "\x00\x01\x021",
+	} {
+		req := sign(contentType, body)
+		tampered := req.Clone(context.Background())
+		tampered.Body = io.NopCloser(strings.NewReader(strings.Replace(body, "1", "9", 1)))
+		if err := verifier.Verify(tampered); !errors.Is(err, ErrSignatureInvalid) {
+			t.Fatalf("%s tampered: err = %v, want ErrSignatureInvalid", contentType, err)
+		}
+		if err := verifier.Verify(req); err != nil {
+			t.Fatalf("%s: %v", contentType, err)
+		}
+	}
+
+	// 请求体超过MaxBodySize时不再读取
+	req := sign(ContentTypeXML, strings.Repeat("a", 65))
+	if err := verifier.Verify(req); !errors.Is(err, ErrSignBodyTooLarge) {
+		t.Fatalf("large body: err = %v, want ErrSignBodyTooLarge", err)
+	}
+}
+
+func TestSignMultipart(t *testing.T) {
+	cfg := SignConfig{Secret: "secret"}
+	verifier := NewVerifier(cfg, time.Minute, nil)
+	ts := httptest.NewServer(verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil || r.FormValue("name") != "report" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithMiddleware(Sign(cfg)))
+	ctx := context.Background()
+
+	// 可以重复读取的multipart请求体通过GetBody计算SHA256，发送的请求体仍然是流式的
+	m := NewMultipart().AddField("name", "report").AddBytes("file", "report.csv", "text/csv", []byte("a,b\n1,2\n"))
+	req, _ := c.NewMultipartRequest(ctx, http.MethodPost, "/upload", m)
+	if _, err := c.Request(req); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只能读取一次的请求体无法签名
+	m = NewMultipart().AddReader("file", "data.bin", "", strings.NewReader("stream"), -1)
+	req, _ = c.NewMultipartRequest(ctx, http.MethodPost, "/upload", m)
+	if _, err := c.Request(req); !errors.Is(err, ErrSignBodyNotReplayable) {
+		t.Fatalf("err = %v, want ErrSignBodyNotReplayable", err)
+	}
+}
+
+func TestSignWithAPIKeyQuery(t *testing.T) {
+	cfg := SignConfig{Secret: "secret"}
+	verifier := NewVerifier(cfg, time.Minute, nil)
+	ts := httptest.NewServer(verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Query().Get("api_key")))
+	})))
+	defer ts.Close()
+
+	// Authenticator在中间件之前执行，查询参数中的API Key参与签名
+	c := NewClient(WithBaseURL(ts.URL), WithAuthenticator(APIKeyQuery("api_key", "k1")), WithMiddleware(Sign(cfg)))
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/orders?id=1", nil)
+	body, err := c.Request(req)
+	if err != nil || string(body) != "k1" {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+}
@@ -0,0 +1,163 @@
+package ghttp
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
+
+const (
+	defaultSignWindow      = 5 * time.Minute
+	defaultSignMaxBodySize = 10 << 20
+)
+
+var (
+	ErrSignatureMissing = gerror.WithCode(gerror.CodeUnauthorized, "缺少签名参数")
+	ErrSignatureInvalid = gerror.WithCode(gerror.CodeUnauthorized, "签名错误")
+	ErrSignatureExpired = gerror.WithCode(gerror.CodeUnauthorized, "签名已过期")
+	ErrNonceReplayed    = gerror.WithCode(gerror.CodeUnauthorized, "重复的请求")
+	ErrSignBodyTooLarge = gerror.WithCode(gerror.CodeCommon, "请求体过大")
+)
+
+// NonceStore 记录使用过的随机数，多实例部署时可以基于Redis实现
+type NonceStore interface {
+	// Add 记录随机数直到expire，随机数已存在时返回false
+	Add(nonce string, expire time.Time) bool
+}
+
+// MemoryNonceStore 保存在内存中的随机数
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) Add(nonce string, expire time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// 每分钟清理一次过期的随机数
+	if now.Sub(s.lastSweep) > time.Minute {
+		for n, exp := range s.nonces {
+			if now.After(exp) {
+				delete(s.nonces, n)
+			}
+		}
+		s.lastSweep = now
+	}
+	if exp, ok := s.nonces[nonce]; ok && !now.After(exp) {
+		return false
+	}
+	s.nonces[nonce] = expire
+	return true
+}
+
+// Verifier 服务端校验Sign中间件生成的签名，并通过时间戳和随机数防止重放
+type Verifier struct {
+	config SignConfig
+	window time.Duration
+	nonces NonceStore
+}
+
+// NewVerifier 创建签名校验，window为允许的时间戳误差，为0时使用5分钟；nonces为nil时保存在内存中
+func NewVerifier(config SignConfig, window time.Duration, nonces NonceStore) *Verifier {
+	if window <= 0 {
+		window = defaultSignWindow
+	}
+	if nonces == nil {
+		nonces = NewMemoryNonceStore()
+	}
+	return &Verifier{
+		config: config.withDefaults(),
+		window: window,
+		nonces: nonces,
+	}
+}
+
+// Verify 校验请求的签名，请求体读取后会恢复，后续仍然可以读取；
+// 请求体超过SignConfig.MaxBodySize时返回ErrSignBodyTooLarge
+func (v *Verifier) Verify(r *http.Request) error {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		b, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, v.config.MaxBodySize))
+		_ = r.Body.Close()
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return ErrSignBodyTooLarge
+			}
+			return err
+		}
+		body = b
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	params, err := v.config.signParams(r.URL.Query(), r.Header.Get("Content-Type"), body)
+	if err != nil {
+		return gerror.Wrap(ErrSignatureInvalid, err.Error())
+	}
+
+	cfg := v.config
+	var timestamp, nonce, signature string
+	if cfg.Placement == SignInHeader {
+		timestamp = r.Header.Get(cfg.TimestampHeader)
+		nonce = r.Header.Get(cfg.NonceHeader)
+		signature = r.Header.Get(cfg.SignatureHeader)
+		params.Set(cfg.TimestampKey, timestamp)
+		params.Set(cfg.NonceKey, nonce)
+	} else {
+		timestamp = params.Get(cfg.TimestampKey)
+		nonce = params.Get(cfg.NonceKey)
+		signature = params.Get(cfg.SignatureKey)
+	}
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrSignatureMissing
+	}
+
+	expected := cfg.Algorithm(cfg.Canonical(params), cfg.Secret)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return ErrSignatureInvalid
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	signedAt := time.Unix(ts, 0)
+	if d := time.Since(signedAt); d > v.window || d < -v.window {
+		return ErrSignatureExpired
+	}
+	// 签名正确后再记录随机数，避免伪造的请求占用随机数
+	if !v.nonces.Add(nonce, signedAt.Add(v.window)) {
+		return ErrNonceReplayed
+	}
+
+	return nil
+}
+
+// Handler 校验失败时返回401，请求体过大时返回413
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrSignBodyTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
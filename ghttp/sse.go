@@ -0,0 +1,291 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReconnectDelay = 3 * time.Second
+	defaultMaxReconnects  = 5
+)
+
+// Event Server-Sent Events事件
+type Event struct {
+	ID    string // 最近一次收到的事件ID，重连时通过Last-Event-ID发送
+	Event string // 事件类型，默认为message
+	Data  []byte // 多行data使用\n连接
+	Retry time.Duration
+}
+
+// StreamOption SSE的配置
+type StreamOption interface {
+	apply(*SSEStream)
+}
+
+type streamOptionFunc func(*SSEStream)
+
+func (f streamOptionFunc) apply(s *SSEStream) {
+	f(s)
+}
+
+// WithMaxReconnects 连续重连失败的最大次数，0为不重连，小于0时一直重连
+func WithMaxReconnects(n int) StreamOption {
+	return streamOptionFunc(func(s *SSEStream) {
+		s.maxReconnects = n
+	})
+}
+
+// WithReconnectDelay 重连前的等待时间，服务端通过retry字段指定时使用服务端的值，默认为3秒
+func WithReconnectDelay(d time.Duration) StreamOption {
+	return streamOptionFunc(func(s *SSEStream) {
+		s.delay = d
+	})
+}
+
+// WithLastEventID 首次连接时发送的Last-Event-ID
+func WithLastEventID(id string) StreamOption {
+	return streamOptionFunc(func(s *SSEStream) {
+		s.lastEventID = id
+	})
+}
+
+// SSEStream 逐个读取SSE事件，连接断开时使用Last-Event-ID自动重连
+//
+//	stream, err := client.SSE(req)
+//	defer stream.Close()
+//	for stream.Next() {
+//		event := stream.Event()
+//	}
+//	err = stream.Err()
+type SSEStream struct {
+	client        *Client
+	req           *http.Request
+	res           *http.Response
+	reader        *bufio.Reader
+	codec         Codec
+	event         Event
+	lastEventID   string
+	delay         time.Duration
+	maxReconnects int
+	failures      int
+	err           error
+	closed        bool
+}
+
+// 确保我们始终实现 Streamer
+var _ Streamer = (*SSEStream)(nil)
+
+// SSE 发送请求并返回事件流，请求的context取消时结束
+func (c *Client) SSE(req *http.Request, opts ...StreamOption) (*SSEStream, error) {
+	s := &SSEStream{
+		client:        c,
+		req:           req,
+		codec:         c.itemCodec(req.Context()),
+		delay:         defaultReconnectDelay,
+		maxReconnects: defaultMaxReconnects,
+	}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *SSEStream) connect() error {
+	req := s.req.Clone(s.req.Context())
+	if s.req.GetBody != nil {
+		body, err := s.req.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	res, err := s.client.Stream(req)
+	if err != nil {
+		return err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); res.StatusCode != http.StatusNoContent && mediaType != "text/event-stream" {
+		_ = res.Body.Close()
+		return fmt.Errorf("http sse error, url=%v, contentType=%v", req.URL.String(), res.Header.Get("Content-Type"))
+	}
+
+	s.res = res
+	s.reader = bufio.NewReader(res.Body)
+	return nil
+}
+
+// Next 读取下一个事件，流结束、出错或context取消时返回false
+func (s *SSEStream) Next() bool {
+	for !s.closed && s.err == nil {
+		// 服务端返回204表示不再重连
+		if s.res != nil && s.res.StatusCode == http.StatusNoContent {
+			return false
+		}
+		if s.res != nil {
+			ok, err := s.readEvent()
+			if ok {
+				s.failures = 0
+				return true
+			}
+			_ = s.res.Body.Close()
+			s.res = nil
+			if ctxErr := s.req.Context().Err(); ctxErr != nil {
+				s.err = ctxErr
+				return false
+			}
+			if err != nil && !errors.Is(err, io.EOF) && !IsRetryableError(err) {
+				s.err = err
+				return false
+			}
+		}
+		if !s.reconnect() {
+			return false
+		}
+	}
+	return false
+}
+
+func (s *SSEStream) reconnect() bool {
+	for {
+		if s.maxReconnects >= 0 && s.failures >= s.maxReconnects {
+			if s.err == nil {
+				s.err = io.EOF
+			}
+			return false
+		}
+		s.failures++
+		if !sleepContext(s.req.Context(), s.delay) {
+			// 等待结束前context就会到期
+			if s.err = s.req.Context().Err(); s.err == nil {
+				s.err = context.DeadlineExceeded
+			}
+			return false
+		}
+		err := s.connect()
+		if err == nil {
+			s.err = nil
+			return true
+		}
+		s.err = err
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) || s.req.Context().Err() != nil {
+			return false
+		}
+	}
+}
+
+// 读取一个完整的事件
+func (s *SSEStream) readEvent() (bool, error) {
+	var (
+		data    bytes.Buffer
+		hasData bool
+		event   string
+		id      = s.lastEventID
+	)
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			// 不完整的事件丢弃
+			return false, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			// 事件结束时才更新Last-Event-ID，不完整的事件不影响重连
+			s.lastEventID = id
+			if !hasData {
+				event = ""
+				continue
+			}
+			if event == "" {
+				event = "message"
+			}
+			s.event = Event{
+				ID:    s.lastEventID,
+				Event: event,
+				Data:  data.Bytes(),
+				Retry: s.delay,
+			}
+			return true, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				id = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// Event 返回当前事件
+func (s *SSEStream) Event() Event {
+	return s.event
+}
+
+// Bytes 返回当前事件的data
+func (s *SSEStream) Bytes() []byte {
+	return s.event.Data
+}
+
+// Codec 返回Decode解析事件数据使用的codec
+func (s *SSEStream) Codec() Codec {
+	return s.codec
+}
+
+// LastEventID 返回最近一次收到的事件ID
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Err 返回导致流结束的错误，正常结束时返回nil
+func (s *SSEStream) Err() error {
+	if errors.Is(s.err, io.EOF) {
+		return nil
+	}
+	return s.err
+}
+
+func (s *SSEStream) Close() error {
+	s.closed = true
+	if s.res != nil {
+		return s.res.Body.Close()
+	}
+	return nil
+}
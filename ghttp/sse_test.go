@@ -0,0 +1,193 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type tick struct {
+	N int `json:"n"`
+}
+
+func TestSSE(t *testing.T) {
+	var (
+		conns  atomic.Int32
+		lastID atomic.Value
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch conns.Add(1) {
+		case 1:
+			fmt.Fprint(w, ": comment\n")
+			fmt.Fprint(w, "retry: 10\n")
+			fmt.Fprint(w, "id: 1\nevent: tick\ndata: {\"n\":1}\n\n")
+			fmt.Fprint(w, "id: 2\r\ndata: line1\r\ndata: line2\r\n\r\n")
+			// 不完整的事件在断开后丢弃
+			fmt.Fprint(w, "id: 3\ndata: partial")
+		case 2:
+			lastID.Store(r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "id: 3\ndata:{\"n\":3}\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/events", nil)
+	stream, err := c.SSE(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var events []Event
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	if err = stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events: %+v", len(events), events)
+	}
+	if e := events[0]; e.ID != "1" || e.Event != "tick" || string(e.Data) != `{"n":1}` || e.Retry != 10*time.Millisecond {
+		t.Fatalf("event 0 = %+v", e)
+	}
+	if e := events[1]; e.ID != "2" || e.Event != "message" || string(e.Data) != "line1\nline2" {
+		t.Fatalf("event 1 = %+v", e)
+	}
+	if lastID.Load() != "2" || string(events[2].Data) != `{"n":3}` {
+		t.Fatalf("reconnect Last-Event-ID = %v, event 2 = %+v", lastID.Load(), events[2])
+	}
+}
+
+func TestSSEChannelCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; ; i++ {
+			if _, err := fmt.Fprintf(w, "data: {\"n\":%d}\n\n", i); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := c.NewRequest(ctx, http.MethodGet, "/", nil)
+	stream, err := c.SSE(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for item := range StreamChannel[tick](ctx, stream) {
+		if item.Err != nil {
+			if !errors.Is(item.Err, context.Canceled) {
+				t.Fatalf("err = %v", item.Err)
+			}
+			break
+		}
+		got = append(got, item.Value.N)
+		if len(got) == 3 {
+			cancel()
+		}
+	}
+	if len(got) < 3 || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/x-ndjson" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte("{\"n\":1}\n\n{\"n\":2}\r\nnot json\n{\"n\":4}"))
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/", nil)
+	accept := req.Header.Get("Accept")
+	stream, err := c.NDJSON(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不修改调用方的请求
+	if req.Header.Get("Accept") != accept {
+		t.Fatalf("Accept changed on caller's request: %q", req.Header.Get("Accept"))
+	}
+
+	var lines []string
+	for item := range StreamChannel[tick](context.Background(), stream) {
+		if item.Err != nil {
+			lines = append(lines, "err")
+			continue
+		}
+		lines = append(lines, fmt.Sprint(item.Value.N))
+	}
+	if strings.Join(lines, ",") != "1,2,err,4" {
+		t.Fatalf("lines = %v", lines)
+	}
+}
+
+// 将数字解码为两倍，用于确认Decode使用了Client的codec
+type doubleCodec struct {
+	JSONCodec
+}
+
+func (doubleCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := (JSONCodec{}).Unmarshal(data, v); err != nil {
+		return err
+	}
+	if t, ok := v.(*tick); ok {
+		t.N *= 2
+	}
+	return nil
+}
+
+func TestStreamDecodeCodec(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte("{\"n\":1}\n{\"n\":2}\n"))
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL), WithCodec(nil), WithResponseCodec(ContentTypeJSON, doubleCodec{}))
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/", nil)
+	stream, err := c.NDJSON(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var sum int
+	for stream.Next() {
+		v, err := Decode[tick](stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += v.N
+	}
+	if sum != 6 {
+		t.Fatalf("sum = %d, want 6", sum)
+	}
+}
@@ -0,0 +1,119 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge 响应体超过ClientConfig.MaxBodySize
+var ErrBodyTooLarge = errors.New("ghttp: response body too large")
+
+// Stream 发送请求并返回未读取的响应，调用方需要关闭res.Body；
+// 使用StreamSuccess判断是否成功，不成功时读取部分响应体并返回*HTTPError
+func (c *Client) Stream(req *http.Request) (*http.Response, error) {
+	res, meta, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.streamSuccess(res) {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		return nil, c.httpCodeError(req, res, meta, body)
+	}
+
+	return res, nil
+}
+
+// 流式响应还没有读取响应体，不能使用需要检查响应体的Success
+func (c *Client) streamSuccess(res *http.Response) bool {
+	if c.config.StreamSuccess != nil {
+		return c.config.StreamSuccess(res, nil)
+	}
+	return Status2xx(res, nil)
+}
+
+// 读取响应体，超过MaxBodySize时返回ErrBodyTooLarge
+func (c *Client) readBody(req *http.Request, res *http.Response) ([]byte, error) {
+	limit := c.config.MaxBodySize
+	if limit <= 0 {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return body, fmt.Errorf("http read body error, url=%v, err=%w", req.URL.String(), err)
+		}
+		return body, nil
+	}
+
+	if res.ContentLength > limit {
+		return nil, fmt.Errorf("%w, url=%v, contentLength=%v, limit=%v", ErrBodyTooLarge, req.URL.String(), res.ContentLength, limit)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, limit+1))
+	if err != nil {
+		return body, fmt.Errorf("http read body error, url=%v, err=%w", req.URL.String(), err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w, url=%v, limit=%v", ErrBodyTooLarge, req.URL.String(), limit)
+	}
+
+	return body, nil
+}
+
+// Streamer 逐条读取的响应流，SSEStream和NDJSONStream实现了该接口
+type Streamer interface {
+	Next() bool
+	Bytes() []byte
+	Err() error
+	Close() error
+}
+
+// StreamItem 从流中解析出的值，Err不为nil时表示解析失败或流异常结束
+type StreamItem[T any] struct {
+	Value T
+	Err   error
+}
+
+// Decode 将当前事件或当前行解析成T，使用创建流时按照Client的配置选择的codec，默认为JSONCodec
+func Decode[T any](s Streamer) (T, error) {
+	var codec Codec = JSONCodec{}
+	if cs, ok := s.(interface{ Codec() Codec }); ok && cs.Codec() != nil {
+		codec = cs.Codec()
+	}
+
+	var v T
+	err := codec.Unmarshal(s.Bytes(), &v)
+	return v, err
+}
+
+// StreamChannel 在goroutine中读取s，将每条数据解析成T发送到channel，流结束后关闭s和channel；
+// ctx应为创建请求时使用的context，取消后停止读取
+func StreamChannel[T any](ctx context.Context, s Streamer) <-chan StreamItem[T] {
+	ch := make(chan StreamItem[T])
+	send := func(item StreamItem[T]) bool {
+		select {
+		case ch <- item:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(ch)
+		defer s.Close()
+
+		for s.Next() {
+			v, err := Decode[T](s)
+			if !send(StreamItem[T]{Value: v, Err: err}) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			send(StreamItem[T]{Err: err})
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,72 @@
+package ghttp
+
+import (
+	"context"
+	"net/http"
+)
+
+type successKey struct{}
+
+// SuccessFunc 判断请求是否成功，body为完整的响应体，返回false时请求返回*HTTPError
+type SuccessFunc func(res *http.Response, body []byte) bool
+
+// StatusRange 状态码在[min, max]之间时成功
+func StatusRange(min, max int) SuccessFunc {
+	return func(res *http.Response, _ []byte) bool {
+		return res.StatusCode >= min && res.StatusCode <= max
+	}
+}
+
+// StatusIn 状态码为codes之一时成功
+func StatusIn(codes ...int) SuccessFunc {
+	return func(res *http.Response, _ []byte) bool {
+		for _, code := range codes {
+			if res.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AllSuccess 全部判断都成功时成功，例如 AllSuccess(Status2xx, 判断业务code为0)
+func AllSuccess(fns ...SuccessFunc) SuccessFunc {
+	return func(res *http.Response, body []byte) bool {
+		for _, fn := range fns {
+			if !fn(res, body) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Status2xx 默认的判断，状态码为2xx时成功
+var Status2xx = StatusRange(200, 299)
+
+// ContextWithSuccess 使用该context创建的请求按照fn判断是否成功，优先于Client的配置
+func ContextWithSuccess(ctx context.Context, fn SuccessFunc) context.Context {
+	return context.WithValue(ctx, successKey{}, fn)
+}
+
+func (c *Client) success(req *http.Request, res *http.Response, body []byte) bool {
+	if fn, ok := req.Context().Value(successKey{}).(SuccessFunc); ok && fn != nil {
+		return fn(res, body)
+	}
+	if c.config.Success != nil {
+		return c.config.Success(res, body)
+	}
+	return Status2xx(res, body)
+}
+
+// 响应体为空时不解析，例如204和HEAD请求
+func emptyBody(body []byte) bool {
+	for _, b := range body {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package ghttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSuccess(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/envelope":
+			_, _ = w.Write([]byte(`{"code":1001,"msg":"balance not enough"}`))
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL))
+
+	var created struct{ ID int }
+	req, _ := c.NewRequest(context.Background(), http.MethodPost, "/created", nil)
+	if err := c.SendRequest(req, &created); err != nil || created.ID != 7 {
+		t.Fatalf("201: id = %d, err = %v", created.ID, err)
+	}
+
+	req, _ = c.NewRequest(context.Background(), http.MethodDelete, "/empty", nil)
+	if err := c.SendRequest(req, &created); err != nil {
+		t.Fatalf("204: %v", err)
+	}
+
+	// 业务code不为0时失败
+	envelope := AllSuccess(Status2xx, func(res *http.Response, body []byte) bool {
+		var v struct{ Code int }
+		return json.Unmarshal(body, &v) == nil && v.Code == 0
+	})
+	c = NewClient(WithBaseURL(ts.URL), WithSuccess(envelope))
+	req, _ = c.NewRequest(context.Background(), http.MethodGet, "/envelope", nil)
+	var httpErr *HTTPError
+	if _, err := c.SendRequestRaw(req); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusOK {
+		t.Fatalf("envelope: err = %v, want *HTTPError", err)
+	}
+
+	// 单个请求的判断优先于Client的配置
+	ctx := ContextWithSuccess(context.Background(), StatusIn(http.StatusOK))
+	req, _ = c.NewRequest(ctx, http.MethodGet, "/accepted", nil)
+	if _, err := c.Request(req); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusAccepted {
+		t.Fatalf("per request: err = %v, want 202 *HTTPError", err)
+	}
+}
@@ -0,0 +1,264 @@
+package ghttp
+
+import (
+	"errors"
+	"fmt"
+	netUrl "net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// BuildPath 展开路径模板并添加查询参数，返回的路径可以传给NewRequest
+//
+//	path, err := ghttp.BuildPath("/users/{id}", map[string]string{"id": "1"}, query)
+func BuildPath(template string, params map[string]string, query interface{}) (string, error) {
+	path, err := ExpandPath(template, params)
+	if err != nil {
+		return "", err
+	}
+	values, err := EncodeQuery(query)
+	if err != nil {
+		return "", err
+	}
+	return appendQuery(path, values), nil
+}
+
+// ExpandPath 将 /users/{id} 中的{id}替换成转义后的参数值
+func ExpandPath(template string, params map[string]string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("ghttp: unclosed path param in %q", template)
+		}
+		end += start
+		name := template[start+1 : end]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("ghttp: missing path param %q", name)
+		}
+		b.WriteString(template[:start])
+		b.WriteString(netUrl.PathEscape(value))
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+
+	return b.String(), nil
+}
+
+// EncodeQuery 将url.Values、map或struct转换成查询参数
+//
+// struct使用url标签指定参数名，例如 `url:"name,omitempty"`，"-"表示忽略该字段；
+// 切片会生成多个同名参数，nil指针会被忽略，time.Time使用RFC3339格式，标签中指定unix时使用秒级时间戳
+func EncodeQuery(v interface{}) (netUrl.Values, error) {
+	values := make(netUrl.Values)
+	if v == nil {
+		return values, nil
+	}
+
+	switch q := v.(type) {
+	case netUrl.Values:
+		for key, vals := range q {
+			values[key] = append(values[key], vals...)
+		}
+		return values, nil
+	case map[string]string:
+		for key, val := range q {
+			values.Add(key, val)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("ghttp: query map key must be string, got %v", rv.Type().Key())
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := addQueryValue(values, iter.Key().String(), iter.Value(), false, false); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Struct:
+		if err := encodeStruct(values, rv); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("ghttp: unsupported query type %T", v)
+	}
+
+	return values, nil
+}
+
+func encodeStruct(values netUrl.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+
+		// 没有标签的匿名结构体展开
+		if field.Anonymous && tag == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := encodeStruct(values, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := hasTagOption(opts, "omitempty")
+		if omitempty && fv.IsZero() {
+			continue
+		}
+		if err := addQueryValue(values, name, fv, omitempty, hasTagOption(opts, "unix")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func addQueryValue(values netUrl.Values, name string, v reflect.Value, omitempty, unix bool) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			values.Add(name, string(v.Bytes()))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := addQueryValue(values, name, v.Index(i), omitempty, unix); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s, err := formatQueryValue(v, unix)
+	if err != nil {
+		return fmt.Errorf("ghttp: query param %q: %v", name, err)
+	}
+	if omitempty && s == "" {
+		return nil
+	}
+	values.Add(name, s)
+	return nil
+}
+
+func formatQueryValue(v reflect.Value, unix bool) (string, error) {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if unix {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", errors.New("unsupported type " + v.Type().String())
+}
+
+// 将查询参数添加到路径中，路径中已有的参数保留在前面
+func appendQuery(path string, values netUrl.Values) string {
+	if len(values) == 0 {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + values.Encode()
+}
+
+// 拼接BaseURL和路径，处理重复或缺少的斜杠，并合并两者的查询参数；路径为完整的URL时直接使用
+func joinURL(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	if u, err := netUrl.Parse(ref); err == nil && u.Scheme != "" && u.Host != "" {
+		return ref
+	}
+
+	basePath, baseQuery, _ := strings.Cut(base, "?")
+	refPath, refQuery, _ := strings.Cut(ref, "?")
+
+	joined := basePath
+	if refPath != "" {
+		joined = strings.TrimRight(basePath, "/") + "/" + strings.TrimLeft(refPath, "/")
+	}
+	switch {
+	case baseQuery != "" && refQuery != "":
+		joined += "?" + baseQuery + "&" + refQuery
+	case baseQuery != "":
+		joined += "?" + baseQuery
+	case refQuery != "":
+		joined += "?" + refQuery
+	}
+
+	return joined
+}
@@ -0,0 +1,107 @@
+package ghttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	netUrl "net/url"
+	"testing"
+	"time"
+)
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		base, ref, want string
+	}{
+		{"", "/users", "/users"},
+		{"http://h", "/users", "http://h/users"},
+		{"http://h/", "/users", "http://h/users"},
+		{"http://h/api", "users", "http://h/api/users"},
+		{"http://h/api/", "/users/", "http://h/api/users/"},
+		{"http://h/api?key=k", "/users?page=2", "http://h/api/users?key=k&page=2"},
+		{"http://h/api?key=k", "", "http://h/api?key=k"},
+		{"http://h/api", "?page=2", "http://h/api?page=2"},
+		{"http://h/api", "https://other/x", "https://other/x"},
+		{"http://h/api", "/files/a%2Fb", "http://h/api/files/a%2Fb"},
+	}
+	for _, tt := range tests {
+		if got := joinURL(tt.base, tt.ref); got != tt.want {
+			t.Errorf("joinURL(%q, %q) = %q, want %q", tt.base, tt.ref, got, tt.want)
+		}
+	}
+}
+
+type pageQuery struct {
+	Page int `url:"page"`
+	Size int `url:"size,omitempty"`
+}
+
+type listQuery struct {
+	pageQuery
+	Name    string    `url:"name,omitempty"`
+	Tags    []string  `url:"tag"`
+	Active  *bool     `url:"active"`
+	Deleted *bool     `url:"deleted"`
+	Since   time.Time `url:"since,omitempty"`
+	Until   time.Time `url:"until,unix"`
+	Score   float64
+	Secret  string `url:"-"`
+}
+
+func TestEncodeQuery(t *testing.T) {
+	active := true
+	q := listQuery{
+		pageQuery: pageQuery{Page: 2},
+		Tags:      []string{"a", "b c"},
+		Active:    &active,
+		Until:     time.Unix(1700000000, 0),
+		Score:     1.5,
+		Secret:    "x",
+	}
+	values, err := EncodeQuery(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Score=1.5&active=true&page=2&tag=a&tag=b+c&until=1700000000"
+	if got := values.Encode(); got != want {
+		t.Fatalf("EncodeQuery = %q, want %q", got, want)
+	}
+
+	values, err = EncodeQuery(map[string]interface{}{"ids": []int{1, 2}, "q": "x"})
+	if err != nil || values.Encode() != "ids=1&ids=2&q=x" {
+		t.Fatalf("EncodeQuery(map) = %q, %v", values.Encode(), err)
+	}
+	if _, err = EncodeQuery(42); err == nil {
+		t.Fatal("expected error for unsupported type")
+	}
+}
+
+func TestBuildPath(t *testing.T) {
+	path, err := BuildPath("/users/{id}/files/{name}", map[string]string{"id": "7", "name": "a b/c"}, netUrl.Values{"v": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/7/files/a%20b%2Fc?v=1"; path != want {
+		t.Fatalf("BuildPath = %q, want %q", path, want)
+	}
+	if _, err = ExpandPath("/users/{id}", nil); err == nil {
+		t.Fatal("expected missing param error")
+	}
+
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RequestURI()
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	c := NewClient(WithBaseURL(ts.URL + "/api/?key=k"))
+	_, _, err = Get[struct{}](context.Background(), c, "/users/{id}",
+		WithPathParam("id", "a/b"), WithQueryParams(pageQuery{Page: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/api/users/a%2Fb?key=k&page=3"; got != want {
+		t.Fatalf("request uri = %q, want %q", got, want)
+	}
+}
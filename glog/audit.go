@@ -0,0 +1,299 @@
+package glog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	FieldKeySeq  = "_seq"
+	FieldKeyPrev = "_prev"
+	FieldKeyHash = "_hash"
+)
+
+var (
+	// 链首行的前一个哈希
+	genesisHash  = strings.Repeat("0", sha256.Size*2)
+	auditEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+)
+
+// 确保我们始终实现 io.Writer
+var _ io.Writer = (*AuditWriter)(nil)
+
+// AuditWriter 审计日志写入器，每行追加序号和哈希链，写入后立即落盘
+// 行格式: _seq= 序号 _prev= 上一行哈希 _hash= 本行哈希 原始日志内容
+// 本行哈希 = SHA-256(上一行哈希 + 序号 + 原始日志内容)，设置Key时使用HMAC-SHA256
+type AuditWriter struct {
+	file     *LogFile
+	key      []byte
+	seq      uint64
+	prevHash string
+	mu       sync.Mutex
+}
+
+// NewAuditWriter 创建审计日志写入器，会从已有的日志文件中恢复序号和哈希链；
+// 进程崩溃导致当前文件最后一行不完整时，截断这一行后继续写入
+func NewAuditWriter(lf *LogFile, key []byte) (*AuditWriter, error) {
+	aw := &AuditWriter{
+		file:     lf,
+		key:      key,
+		prevHash: genesisHash,
+	}
+
+	if err := truncateTornLine(lf.filename()); err != nil {
+		return nil, err
+	}
+	files, err := lf.logFiles()
+	if err != nil {
+		return nil, err
+	}
+	// 从最新的文件开始查找最后一条审计记录
+	for i := len(files) - 1; i >= 0; i-- {
+		line, err := lastAuditLine(files[i], lf.Compressor)
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			continue
+		}
+		aw.seq = line.seq
+		aw.prevHash = line.hash
+		break
+	}
+
+	return aw, nil
+}
+
+func (aw *AuditWriter) Write(p []byte) (n int, err error) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	content := escapeAuditContent(p)
+	seq := aw.seq + 1
+	sum := auditHash(aw.key, aw.prevHash, seq, content)
+
+	b := bufferPool.Get()
+	defer func() {
+		b.Reset()
+		bufferPool.Put(b)
+	}()
+	b.Reset()
+	fmt.Fprintf(b, "%s= %d %s= %s %s= %s %s\n", FieldKeySeq, seq, FieldKeyPrev, aw.prevHash, FieldKeyHash, sum, content)
+
+	if _, err = aw.file.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	if err = aw.file.Sync(); err != nil {
+		return 0, err
+	}
+
+	aw.seq = seq
+	aw.prevHash = sum
+
+	return len(p), nil
+}
+
+func (aw *AuditWriter) Close() error {
+	return aw.file.Close()
+}
+
+// Verify 从链首开始校验审计日志文件(包括轮转和压缩的备份文件)的哈希链，
+// 旧的备份文件被清理后会校验失败，此时使用VerifyAuditFrom
+func (aw *AuditWriter) Verify() error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	files, err := aw.file.logFiles()
+	if err != nil {
+		return err
+	}
+
+	return verifyAudit(aw.key, files, nil, aw.file.Compressor)
+}
+
+// AuditError 哈希链中第一个断开的位置
+type AuditError struct {
+	File   string
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (e *AuditError) Error() string {
+	return fmt.Sprintf("audit chain broken, file=%v, line=%v, seq=%v, reason=%v", e.File, e.Line, e.Seq, e.Reason)
+}
+
+// VerifyAudit 按顺序(从旧到新)校验审计日志文件，返回第一个断开的位置；
+// 第一行必须是链首(序号为1)，删除开头的记录也能发现
+func VerifyAudit(key []byte, files ...string) error {
+	return verifyAudit(key, files, nil)
+}
+
+// VerifyAuditFrom 旧的备份文件已被清理时使用，files的第一行必须紧接在序号为seq、哈希为hash的记录之后，
+// seq和hash应当在清理前从被清理的文件中记录下来
+func VerifyAuditFrom(key []byte, seq uint64, hash string, files ...string) error {
+	return verifyAudit(key, files, &auditLine{seq: seq, hash: hash})
+}
+
+func verifyAudit(key []byte, files []string, anchor *auditLine, extra ...Compressor) error {
+	prev := anchor
+	if prev == nil {
+		prev = &auditLine{hash: genesisHash}
+	}
+
+	for _, name := range files {
+		err := scanAuditFile(name, extra, func(lineNo int, line *auditLine, err error) error {
+			if err != nil {
+				return &AuditError{File: name, Line: lineNo, Reason: err.Error()}
+			}
+			if line.seq != prev.seq+1 {
+				return &AuditError{File: name, Line: lineNo, Seq: line.seq,
+					Reason: fmt.Sprintf("expected seq %d", prev.seq+1)}
+			}
+			if line.prevHash != prev.hash {
+				return &AuditError{File: name, Line: lineNo, Seq: line.seq, Reason: "previous hash mismatch"}
+			}
+			if sum := auditHash(key, line.prevHash, line.seq, line.content); !hmac.Equal([]byte(sum), []byte(line.hash)) {
+				return &AuditError{File: name, Line: lineNo, Seq: line.seq, Reason: "hash mismatch"}
+			}
+			prev = line
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type auditLine struct {
+	seq      uint64
+	prevHash string
+	hash     string
+	content  string
+}
+
+func parseAuditLine(s string) (*auditLine, error) {
+	parts := strings.SplitN(s, " ", 7)
+	if len(parts) != 7 ||
+		parts[0] != FieldKeySeq+"=" || parts[2] != FieldKeyPrev+"=" || parts[4] != FieldKeyHash+"=" {
+		return nil, fmt.Errorf("malformed audit line")
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed audit seq: %v", err)
+	}
+
+	return &auditLine{seq: seq, prevHash: parts[3], hash: parts[5], content: parts[6]}, nil
+}
+
+func scanAuditFile(name string, extra []Compressor, fn func(lineNo int, line *auditLine, err error) error) error {
+	rc, err := openLogFile(name, extra...)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	r := bufio.NewReader(rc)
+	for lineNo := 1; ; lineNo++ {
+		s, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if s = strings.TrimSuffix(s, "\n"); s != "" {
+			line, errParse := parseAuditLine(s)
+			if errFn := fn(lineNo, line, errParse); errFn != nil {
+				return errFn
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func lastAuditLine(name string, extra ...Compressor) (*auditLine, error) {
+	var last *auditLine
+	err := scanAuditFile(name, extra, func(lineNo int, line *auditLine, err error) error {
+		if err != nil {
+			return fmt.Errorf("can't recover audit chain from %s:%d: %v", name, lineNo, err)
+		}
+		last = line
+		return nil
+	})
+
+	return last, err
+}
+
+// 截断文件末尾没有换行符的不完整行，每次写入都以换行符结尾并立即落盘，只有崩溃时才会出现
+func truncateTornLine(name string) error {
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	buf := make([]byte, 4096)
+	for end := size; end > 0; {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if keep := start + int64(i) + 1; keep < size {
+				return f.Truncate(keep)
+			}
+			return nil
+		}
+		end = start
+	}
+	if size > 0 {
+		return f.Truncate(0)
+	}
+	return nil
+}
+
+func auditHash(key []byte, prevHash string, seq uint64, content string) string {
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(prevHash))
+	h.Write([]byte{' '})
+	h.Write([]byte(strconv.FormatUint(seq, 10)))
+	h.Write([]byte{' '})
+	h.Write([]byte(content))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 一条日志只占一行，内容中的换行符需要转义
+func escapeAuditContent(p []byte) string {
+	return auditEscaper.Replace(string(bytes.TrimRight(p, "\n")))
+}
@@ -0,0 +1,118 @@
+package glog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditWriter(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("secret")
+	lf := &LogFile{Filename: filepath.Join(dir, "audit.log"), Compress: true}
+
+	aw, err := NewAuditWriter(lf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := newLog(WithOutput(aw), WithFormatter(&TextFormatter{DisableColor: true}))
+	log.Info("login")
+	log.WithField(Field{Key: "user", Value: "admin"}).Warn("delete\nall")
+	if err := lf.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	// Close等待后台压缩完成，之后的写入会重新打开文件
+	if err := lf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("logout")
+	_ = aw.Close()
+
+	// 重新打开后哈希链继续
+	aw, err = NewAuditWriter(lf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aw.seq != 3 {
+		t.Fatalf("seq = %d, want 3", aw.seq)
+	}
+	log.SetOutput(aw)
+	log.Info("again")
+	if err := aw.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAudit([]byte("wrong"), lf.Filename); err == nil {
+		t.Fatal("expected hash mismatch with wrong key")
+	}
+
+	// 篡改当前文件的内容
+	data, err := os.ReadFile(lf.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lf.Filename, []byte(strings.Replace(string(data), "logout", "logoff", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err = aw.Verify()
+	var auditErr *AuditError
+	if !errors.As(err, &auditErr) || auditErr.Seq != 3 || auditErr.Line != 1 {
+		t.Fatalf("unexpected verify result: %v", err)
+	}
+}
+
+func TestAuditRecovery(t *testing.T) {
+	dir := t.TempDir()
+	lf := &LogFile{Filename: filepath.Join(dir, "audit.log")}
+	aw, err := NewAuditWriter(lf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		if _, err = aw.Write([]byte(msg + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = aw.Close()
+
+	// 模拟崩溃时最后一行只写入了一部分
+	f, err := os.OpenFile(lf.Filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(FieldKeySeq + "= 4 " + FieldKeyPrev + "= ab")
+	_ = f.Close()
+
+	aw, err = NewAuditWriter(lf, nil)
+	if err != nil {
+		t.Fatalf("reopen after torn line: %v", err)
+	}
+	if aw.seq != 3 {
+		t.Fatalf("seq = %d, want 3", aw.seq)
+	}
+	if _, err = aw.Write([]byte("d\n")); err != nil {
+		t.Fatal(err)
+	}
+	_ = aw.Close()
+	if err = VerifyAudit(nil, lf.Filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// 删除开头的记录
+	data, _ := os.ReadFile(lf.Filename)
+	lines := strings.SplitAfter(string(data), "\n")
+	if err = os.WriteFile(lf.Filename, []byte(strings.Join(lines[1:], "")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var auditErr *AuditError
+	if err = VerifyAudit(nil, lf.Filename); !errors.As(err, &auditErr) || auditErr.Seq != 2 {
+		t.Fatalf("head truncation not detected: %v", err)
+	}
+
+	// 已知被删除的最后一条记录时可以继续校验
+	first, _ := parseAuditLine(strings.TrimSuffix(lines[0], "\n"))
+	if err = VerifyAuditFrom(nil, first.seq, first.hash, lf.Filename); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+package glog
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// 当前包名，调用栈中属于该包的函数都会被跳过
+var logPackage = getPackageName(runtime.FuncForPC(reflect.ValueOf(getPackageName).Pointer()).Name())
+
+func getPackageName(f string) string {
+	for {
+		lastPeriod := strings.LastIndex(f, ".")
+		lastSlash := strings.LastIndex(f, "/")
+		if lastPeriod > lastSlash {
+			f = f[:lastPeriod]
+		} else {
+			break
+		}
+	}
+
+	return f
+}
+
+// 检索第一个非log调用函数，再向上跳过skip层
+func (log *Log) getCaller(skip int) *runtime.Frame {
+	var pcs [maximumCallerDepth]uintptr
+	depth := runtime.Callers(minimumCallerDepth, pcs[:])
+
+	found := false
+	for _, pc := range pcs[:depth] {
+		for _, f := range log.framesForPC(pc) {
+			// 如果调用者不是这个包的一部分，就完成了
+			if !found {
+				if getPackageName(f.Function) == logPackage {
+					continue
+				}
+				found = true
+			}
+			if skip == 0 {
+				frame := f
+				return &frame
+			}
+			skip--
+		}
+	}
+
+	return nil
+}
+
+// 一个pc可能对应多个内联的函数，缓存pc对应的调用帧
+func (log *Log) framesForPC(pc uintptr) []runtime.Frame {
+	if frames, ok := log.frames.Load(pc); ok {
+		return frames.([]runtime.Frame)
+	}
+
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames([]uintptr{pc})
+	for {
+		f, more := iter.Next()
+		frames = append(frames, f)
+		if !more {
+			break
+		}
+	}
+	log.frames.Store(pc, frames)
+
+	return frames
+}
@@ -0,0 +1,103 @@
+package glog_test
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+const testPackage = "github.com/yueluoa/infrastructure/glog_test."
+
+// 返回调用者所在的行号
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func assertCaller(t *testing.T, buf *bytes.Buffer, function string, l int) {
+	t.Helper()
+	out := buf.String()
+	buf.Reset()
+	if !strings.Contains(out, "func= "+testPackage+function+" ") {
+		t.Fatalf("caller function should be %s: %q", function, out)
+	}
+	if !strings.Contains(out, "caller_test.go:"+strconv.Itoa(l)+" ") {
+		t.Fatalf("caller line should be %d: %q", l, out)
+	}
+}
+
+func newCallerLog(buf *bytes.Buffer, skip int) *glog.Log {
+	return &glog.Log{
+		Out:          buf,
+		Formatter:    &glog.TextFormatter{},
+		Level:        glog.InfoLevel,
+		ReportCaller: true,
+		CallerSkip:   skip,
+	}
+}
+
+// 业务中封装的日志方法
+func logWrapped(log *glog.Log, msg string) {
+	log.Info(msg)
+}
+
+func entryWrapped(entry *glog.Entry, msg string) {
+	entry.AddCallerSkip(1).Info(msg)
+}
+
+func TestCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newCallerLog(buf, 0)
+
+	log.Info("direct")
+	assertCaller(t, buf, "TestCaller", line()-1)
+
+	child := log.WithField(glog.Field{Key: "k", Value: "v"})
+	child.Infof("child %d", 1)
+	assertCaller(t, buf, "TestCaller", line()-1)
+
+	entryWrapped(child, "entry wrapped")
+	assertCaller(t, buf, "TestCaller", line()-1)
+
+	wrapped := newCallerLog(buf, 1)
+	logWrapped(wrapped, "log wrapped")
+	assertCaller(t, buf, "TestCaller", line()-1)
+
+	// 不跳过时报告封装函数
+	logWrapped(log, "wrapper reported")
+	if !strings.Contains(buf.String(), "func= "+testPackage+"logWrapped ") {
+		t.Fatalf("caller should be the wrapper: %q", buf.String())
+	}
+	buf.Reset()
+
+	// 修改的是全局Log，测试结束后恢复
+	std := glog.New()
+	out, formatter, reportCaller := std.Out, std.Formatter, std.ReportCaller
+	t.Cleanup(func() {
+		std.SetOutput(out)
+		std.SetFormatter(formatter)
+		std.SetReportCaller(reportCaller)
+	})
+	std.SetOutput(buf)
+	std.SetFormatter(&glog.TextFormatter{})
+	std.SetReportCaller(true)
+	glog.Info("package level")
+	assertCaller(t, buf, "TestCaller", line()-1)
+	glog.WithField(glog.Field{Key: "k", Value: "v"}).Warn("package level child")
+	assertCaller(t, buf, "TestCaller", line()-1)
+	glog.Warning("package level warning")
+	assertCaller(t, buf, "TestCaller", line()-1)
+	glog.Warningf("package level %s", "warningf")
+	assertCaller(t, buf, "TestCaller", line()-1)
+}
+
+func BenchmarkCaller(b *testing.B) {
+	log := newCallerLog(&bytes.Buffer{}, 0)
+	for i := 0; i < b.N; i++ {
+		log.Info("bench")
+	}
+}
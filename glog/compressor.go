@@ -0,0 +1,89 @@
+package glog
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Compressor 轮转后日志文件的压缩算法，例如基于第三方库实现zstd
+type Compressor interface {
+	Suffix() string // 压缩文件的后缀，例如 ".gz"、".zst"
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// GzipCompressor gzip压缩，Level为nil时使用默认压缩级别，可以设置为gzip.NoCompression等级别
+type GzipCompressor struct {
+	Level *int
+}
+
+func (gc *GzipCompressor) Suffix() string {
+	return compressSuffix
+}
+
+func (gc *GzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := gzip.DefaultCompression
+	if gc.Level != nil {
+		level = *gc.Level
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+func (gc *GzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{compressSuffix: &GzipCompressor{}}
+)
+
+// RegisterCompressor 注册压缩算法，读取日志文件(LogReader、VerifyAudit)时按后缀选择解压算法
+func RegisterCompressor(c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c.Suffix()] = c
+}
+
+// 返回已注册的和额外指定的压缩算法，额外指定的优先
+func getCompressors(extra ...Compressor) []Compressor {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+
+	list := make([]Compressor, 0, len(compressors)+len(extra))
+	seen := make(map[string]bool, len(compressors)+len(extra))
+	for _, c := range extra {
+		if c != nil && !seen[c.Suffix()] {
+			seen[c.Suffix()] = true
+			list = append(list, c)
+		}
+	}
+	for suffix, c := range compressors {
+		if !seen[suffix] {
+			seen[suffix] = true
+			list = append(list, c)
+		}
+	}
+
+	return list
+}
+
+// 返回文件名对应的压缩算法
+func compressorFor(name string, list []Compressor) (Compressor, bool) {
+	for _, c := range list {
+		if strings.HasSuffix(name, c.Suffix()) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// 去掉文件名的压缩后缀
+func trimCompressSuffix(name string, list []Compressor) (string, bool) {
+	if c, ok := compressorFor(name, list); ok {
+		return name[:len(name)-len(c.Suffix())], true
+	}
+	return name, false
+}
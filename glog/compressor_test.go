@@ -0,0 +1,113 @@
+package glog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type zlibCompressor struct{}
+
+func (zc *zlibCompressor) Suffix() string { return ".zz" }
+
+func (zc *zlibCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+func (zc *zlibCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+func TestCompressor(t *testing.T) {
+	// 每次轮转的备份文件使用不同的时间戳
+	now := time.Now()
+	currentTime = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	t.Cleanup(func() { currentTime = time.Now })
+
+	dir := t.TempDir()
+	lf := &LogFile{
+		Filename:            filepath.Join(dir, "app.log"),
+		Compress:            true,
+		Compressor:          &zlibCompressor{},
+		CompressDelay:       1,
+		CompressConcurrency: 2,
+	}
+	log := newLog(WithOutput(lf))
+	for i := 0; i < 4; i++ {
+		log.Infof("line %d", i)
+		if err := lf.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Close等待压缩完成
+	if err := lf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plain, compressed int
+	for _, e := range entries {
+		switch {
+		case e.Name() == "app.log":
+		case strings.HasSuffix(e.Name(), ".zz"):
+			compressed++
+		default:
+			plain++
+		}
+	}
+	if plain != 1 || compressed != 3 {
+		t.Fatalf("plain = %d, compressed = %d, want 1 and 3", plain, compressed)
+	}
+
+	var n int
+	err = NewLogReader(lf).Read(context.Background(), Query{}, func(r *Record) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 4 {
+		t.Fatalf("read %d records, err = %v", n, err)
+	}
+}
+
+func TestGzipCompressorLevel(t *testing.T) {
+	data := strings.Repeat("no compression ", 10)
+	compress := func(gc *GzipCompressor) []byte {
+		var buf bytes.Buffer
+		w, err := gc.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(data))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+
+	level := gzip.NoCompression
+	if stored := compress(&GzipCompressor{Level: &level}); !bytes.Contains(stored, []byte(data)) {
+		t.Fatal("Level 0 should use gzip.NoCompression")
+	}
+	if compressed := compress(&GzipCompressor{}); bytes.Contains(compressed, []byte(data)) {
+		t.Fatal("nil Level should use the default compression level")
+	}
+
+	cfg, err := ParseJSONConfig([]byte(`{"sinks":[{"type":"file","file":{"filename":"app.log","compress_level":0}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := cfg.Sinks[0].File.CompressLevel; l == nil || *l != gzip.NoCompression {
+		t.Fatalf("compress_level = %v, want 0", l)
+	}
+}
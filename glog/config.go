@@ -0,0 +1,339 @@
+package glog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultEnvPrefix = "GLOG"
+
+const (
+	FormatterText = "text"
+	FormatterJSON = "json"
+
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"
+)
+
+// Config 日志配置，可以从JSON、YAML和环境变量中加载
+type Config struct {
+	Level        string          `json:"level" yaml:"level"`                 // 日志级别，默认为info
+	ReportCaller bool            `json:"report_caller" yaml:"report_caller"` // 是否标记调用信息
+	Formatter    FormatterConfig `json:"formatter" yaml:"formatter"`
+	Sinks        []SinkConfig    `json:"sinks" yaml:"sinks"` // 输出目标，默认输出到stderr
+	Sampling     *SamplingConfig `json:"sampling" yaml:"sampling"`
+	Dedup        Duration        `json:"dedup" yaml:"dedup"` // 合并连续重复日志的窗口，默认不合并
+}
+
+type FormatterConfig struct {
+	Type            string `json:"type" yaml:"type"`                         // text(默认)或json
+	DisableColor    bool   `json:"disable_color" yaml:"disable_color"`       // text格式是否禁用颜色
+	TimestampFormat string `json:"timestamp_format" yaml:"timestamp_format"` // json格式的时间格式
+}
+
+type SinkConfig struct {
+	Type  string        `json:"type" yaml:"type"`   // stdout、stderr或file
+	Level string        `json:"level" yaml:"level"` // 该输出目标的日志级别，默认不过滤
+	File  LogFileConfig `json:"file" yaml:"file"`   // type为file时的文件配置
+}
+
+// LogFileConfig 对应LogFile的轮转、保留和压缩配置
+type LogFileConfig struct {
+	Filename            string `json:"filename" yaml:"filename"`
+	MaxSize             int    `json:"max_size" yaml:"max_size"`
+	MaxAge              int    `json:"max_age" yaml:"max_age"`
+	MaxBackups          int    `json:"max_backups" yaml:"max_backups"`
+	Compress            bool   `json:"compress" yaml:"compress"`
+	CompressLevel       *int   `json:"compress_level" yaml:"compress_level"` // gzip压缩级别，不设置时为默认压缩级别
+	CompressDelay       int    `json:"compress_delay" yaml:"compress_delay"`
+	CompressConcurrency int    `json:"compress_concurrency" yaml:"compress_concurrency"`
+}
+
+type SamplingConfig struct {
+	Tick       Duration `json:"tick" yaml:"tick"`
+	Initial    int      `json:"initial" yaml:"initial"`
+	Thereafter int      `json:"thereafter" yaml:"thereafter"`
+}
+
+// Duration 支持 "1s"、"500ms" 格式的时间间隔
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// ParseJSONConfig 解析JSON格式的日志配置
+func ParseJSONConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("glog config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ParseYAMLConfig 解析YAML格式的日志配置
+func ParseYAMLConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("glog config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadEnv 使用环境变量覆盖配置，prefix为空时使用GLOG
+//
+//	GLOG_LEVEL、GLOG_REPORT_CALLER、GLOG_FORMATTER、GLOG_DISABLE_COLOR
+//	GLOG_FILE、GLOG_FILE_MAX_SIZE、GLOG_FILE_MAX_AGE、GLOG_FILE_MAX_BACKUPS、GLOG_FILE_COMPRESS
+//
+// GLOG_FILE开头的变量修改第一个file输出目标，不存在时新增一个
+func (cfg *Config) LoadEnv(prefix string) error {
+	if prefix == "" {
+		prefix = defaultEnvPrefix
+	}
+	env := func(name string) (string, bool) {
+		return os.LookupEnv(prefix + "_" + name)
+	}
+
+	var errs []error
+	envBool := func(name string, dst *bool) {
+		if v, ok := env(name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_%s: %v", prefix, name, err))
+				return
+			}
+			*dst = b
+		}
+	}
+	envInt := func(name string, dst *int) {
+		if v, ok := env(name); ok {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_%s: %v", prefix, name, err))
+				return
+			}
+			*dst = i
+		}
+	}
+
+	if v, ok := env("LEVEL"); ok {
+		cfg.Level = v
+	}
+	if v, ok := env("FORMATTER"); ok {
+		cfg.Formatter.Type = v
+	}
+	envBool("REPORT_CALLER", &cfg.ReportCaller)
+	envBool("DISABLE_COLOR", &cfg.Formatter.DisableColor)
+
+	var file *LogFileConfig
+	for i := range cfg.Sinks {
+		if cfg.Sinks[i].Type == SinkFile {
+			file = &cfg.Sinks[i].File
+			break
+		}
+	}
+	if file == nil {
+		if v, ok := env("FILE"); ok {
+			cfg.Sinks = append(cfg.Sinks, SinkConfig{Type: SinkFile, File: LogFileConfig{Filename: v}})
+			file = &cfg.Sinks[len(cfg.Sinks)-1].File
+		}
+	} else if v, ok := env("FILE"); ok {
+		file.Filename = v
+	}
+	if file != nil {
+		envInt("FILE_MAX_SIZE", &file.MaxSize)
+		envInt("FILE_MAX_AGE", &file.MaxAge)
+		envInt("FILE_MAX_BACKUPS", &file.MaxBackups)
+		envBool("FILE_COMPRESS", &file.Compress)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("glog config: %w", errors.Join(errs...))
+	}
+	return cfg.Validate()
+}
+
+// Validate 校验配置，返回全部错误
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if cfg.Level != "" {
+		if _, err := ParseLevel(cfg.Level); err != nil {
+			errs = append(errs, fmt.Errorf("level: %v", err))
+		}
+	}
+	switch cfg.Formatter.Type {
+	case "", FormatterText, FormatterJSON:
+	default:
+		errs = append(errs, fmt.Errorf("formatter.type: unknown formatter %q, want text or json", cfg.Formatter.Type))
+	}
+	for i, sink := range cfg.Sinks {
+		switch sink.Type {
+		case SinkStdout, SinkStderr:
+		case SinkFile:
+			if sink.File.Filename == "" {
+				errs = append(errs, fmt.Errorf("sinks[%d].file.filename: required for file sink", i))
+			}
+			if sink.File.MaxSize < 0 || sink.File.MaxAge < 0 || sink.File.MaxBackups < 0 {
+				errs = append(errs, fmt.Errorf("sinks[%d].file: max_size, max_age and max_backups must not be negative", i))
+			}
+			if level := sink.File.CompressLevel; level != nil && (*level < gzip.HuffmanOnly || *level > gzip.BestCompression) {
+				errs = append(errs, fmt.Errorf("sinks[%d].file.compress_level: must be between %d and %d", i, gzip.HuffmanOnly, gzip.BestCompression))
+			}
+			if sink.File.CompressDelay < 0 || sink.File.CompressConcurrency < 0 {
+				errs = append(errs, fmt.Errorf("sinks[%d].file: compress_delay and compress_concurrency must not be negative", i))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("sinks[%d].type: unknown sink %q, want stdout, stderr or file", i, sink.Type))
+		}
+		if sink.Level != "" {
+			if _, err := ParseLevel(sink.Level); err != nil {
+				errs = append(errs, fmt.Errorf("sinks[%d].level: %v", i, err))
+			}
+		}
+	}
+	if s := cfg.Sampling; s != nil {
+		if s.Tick <= 0 {
+			errs = append(errs, errors.New("sampling.tick: must be positive"))
+		}
+		if s.Initial < 0 || s.Thereafter < 0 {
+			errs = append(errs, errors.New("sampling: initial and thereafter must not be negative"))
+		}
+	}
+	if cfg.Dedup < 0 {
+		errs = append(errs, errors.New("dedup: must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("glog config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Options 将配置转换成Option，可以传给New创建全局Log；
+// 返回的io.Closer关闭配置中创建的LogFile，退出前调用以写入缓冲并停止压缩goroutine
+func (cfg *Config) Options() ([]Option, io.Closer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var opts []Option
+	if cfg.Level != "" {
+		level, _ := ParseLevel(cfg.Level)
+		opts = append(opts, WithLevel(level))
+	}
+	opts = append(opts, WithReportCaller(cfg.ReportCaller))
+
+	switch cfg.Formatter.Type {
+	case FormatterJSON:
+		opts = append(opts, WithFormatter(&JSONFormatter{TimestampFormat: cfg.Formatter.TimestampFormat}))
+	default:
+		opts = append(opts, WithFormatter(&TextFormatter{DisableColor: cfg.Formatter.DisableColor}))
+	}
+
+	out, files := cfg.output()
+	if out != nil {
+		opts = append(opts, WithOutput(out))
+	}
+	if s := cfg.Sampling; s != nil {
+		opts = append(opts, WithSampling(time.Duration(s.Tick), s.Initial, s.Thereafter))
+	}
+	if cfg.Dedup > 0 {
+		opts = append(opts, WithDedup(time.Duration(cfg.Dedup)))
+	}
+
+	return opts, files, nil
+}
+
+// logFiles 配置中创建的LogFile
+type logFiles []*LogFile
+
+func (files logFiles) Close() error {
+	var errs []error
+	for _, lf := range files {
+		if err := lf.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (cfg *Config) output() (io.Writer, logFiles) {
+	if len(cfg.Sinks) == 0 {
+		return nil, nil
+	}
+
+	var files logFiles
+	sinks := make([]Sink, 0, len(cfg.Sinks))
+	for _, sc := range cfg.Sinks {
+		sink := Sink{Level: DebugLevel}
+		if sc.Level != "" {
+			sink.Level, _ = ParseLevel(sc.Level)
+		}
+		switch sc.Type {
+		case SinkStdout:
+			sink.Writer = os.Stdout
+		case SinkStderr:
+			sink.Writer = os.Stderr
+		case SinkFile:
+			lf := &LogFile{
+				Filename:            sc.File.Filename,
+				MaxSize:             sc.File.MaxSize,
+				MaxAge:              sc.File.MaxAge,
+				MaxBackups:          sc.File.MaxBackups,
+				Compress:            sc.File.Compress,
+				Compressor:          &GzipCompressor{Level: sc.File.CompressLevel},
+				CompressDelay:       sc.File.CompressDelay,
+				CompressConcurrency: sc.File.CompressConcurrency,
+			}
+			files = append(files, lf)
+			sink.Writer = lf
+		}
+		sinks = append(sinks, sink)
+	}
+	// 只有一个不过滤级别的输出目标时直接输出，保留stderr的颜色
+	if len(sinks) == 1 && cfg.Sinks[0].Level == "" {
+		return sinks[0].Writer, files
+	}
+
+	return NewMultiSink(sinks...), files
+}
+
+// NewFromConfig 根据配置创建Log，与New不同，每次调用都会创建新的Log；
+// 不再使用Log时调用返回的io.Closer关闭配置中创建的LogFile
+func NewFromConfig(cfg *Config) (*Log, io.Closer, error) {
+	opts, closer, err := cfg.Options()
+	if err != nil {
+		return nil, nil, err
+	}
+	return newLog(opts...), closer, nil
+}
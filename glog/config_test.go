@@ -0,0 +1,78 @@
+package glog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`
+level: debug
+formatter:
+  type: json
+sinks:
+  - type: file
+    level: error
+    file:
+      filename: ` + filepath.Join(dir, "error.log") + `
+      max_size: 10
+      compress: true
+  - type: file
+    file:
+      filename: ` + filepath.Join(dir, "all.log") + `
+sampling:
+  tick: 1s
+  initial: 2
+  thereafter: 0
+`)
+	cfg, err := ParseYAMLConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, closer, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		log.Debug("debug")
+	}
+	log.Error("error")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	all, _ := os.ReadFile(filepath.Join(dir, "all.log"))
+	errs, _ := os.ReadFile(filepath.Join(dir, "error.log"))
+	if strings.Count(string(all), "\n") != 3 || strings.Count(string(errs), "\n") != 1 {
+		t.Fatalf("unexpected output:\n%s\n%s", all, errs)
+	}
+	if log.Stats().Sampled != 1 {
+		t.Fatalf("sampled = %d, want 1", log.Stats().Sampled)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	_, err := ParseJSONConfig([]byte(`{"level":"verbose","sinks":[{"type":"kafka"},{"type":"file"}]}`))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"level: unknown log level", "sinks[0].type", "sinks[1].file.filename"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q missing %q", err, want)
+		}
+	}
+
+	t.Setenv("GLOG_LEVEL", "warn")
+	t.Setenv("GLOG_FILE", "app.log")
+	t.Setenv("GLOG_FILE_MAX_BACKUPS", "3")
+	cfg := &Config{}
+	if err := cfg.LoadEnv(""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "warn" || len(cfg.Sinks) != 1 || cfg.Sinks[0].File.MaxBackups != 3 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
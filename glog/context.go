@@ -0,0 +1,168 @@
+package glog
+
+import "context"
+
+const FieldKeyCtxErr = "ctx_err"
+
+// ContextExtractor 从context中提取日志字段，例如trace id
+type ContextExtractor func(ctx context.Context) []Field
+
+type ctxLevelKey struct{}
+
+// ContextWithLevel 为context设置日志级别，使用该context输出日志时覆盖Log的级别
+func ContextWithLevel(ctx context.Context, level Level) context.Context {
+	return context.WithValue(ctx, ctxLevelKey{}, level)
+}
+
+func LevelFromContext(ctx context.Context) (Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	level, ok := ctx.Value(ctxLevelKey{}).(Level)
+	return level, ok
+}
+
+// IsLevelEnabledContext 检查日志级别，优先使用context中设置的级别
+func (log *Log) IsLevelEnabledContext(ctx context.Context, level Level) bool {
+	if l, ok := LevelFromContext(ctx); ok {
+		return l >= level
+	}
+	return log.IsLevelEnabled(level)
+}
+
+// 提取context中的字段，context已取消时追加取消原因
+func (log *Log) contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	log.mu.Lock()
+	extractors := log.ContextExtractors
+	log.mu.Unlock()
+
+	var fields []Field
+	for _, extract := range extractors {
+		fields = append(fields, extract(ctx)...)
+	}
+	if err := ctx.Err(); err != nil {
+		fields = append(fields, Field{Key: FieldKeyCtxErr, Value: err})
+	}
+
+	return fields
+}
+
+func (log *Log) logContext(ctx context.Context, level Level, args ...interface{}) {
+	if log.shouldLog(ctx, level) {
+		entry := log.newEntry()
+		defer log.putEntry(entry)
+		entry.Ctx = ctx
+		entry.log(level, args...)
+	}
+}
+
+func (log *Log) logfContext(ctx context.Context, level Level, format string, args ...interface{}) {
+	if log.shouldLog(ctx, level) {
+		entry := log.newEntry()
+		defer log.putEntry(entry)
+		entry.Ctx = ctx
+		entry.logf(level, format, args...)
+	}
+}
+
+func (log *Log) DebugContext(ctx context.Context, args ...interface{}) {
+	log.logContext(ctx, DebugLevel, args...)
+}
+
+func (log *Log) InfoContext(ctx context.Context, args ...interface{}) {
+	log.logContext(ctx, InfoLevel, args...)
+}
+
+func (log *Log) WarnContext(ctx context.Context, args ...interface{}) {
+	log.logContext(ctx, WarnLevel, args...)
+}
+
+func (log *Log) ErrorContext(ctx context.Context, args ...interface{}) {
+	log.logContext(ctx, ErrorLevel, args...)
+}
+
+func (log *Log) FatalContext(ctx context.Context, args ...interface{}) {
+	log.logContext(ctx, FatalLevel, args...)
+	log.Exit()
+}
+
+func (log *Log) PanicContext(ctx context.Context, args ...interface{}) {
+	log.logContext(ctx, PanicLevel, args...)
+}
+
+func (log *Log) DebugfContext(ctx context.Context, format string, args ...interface{}) {
+	log.logfContext(ctx, DebugLevel, format, args...)
+}
+
+func (log *Log) InfofContext(ctx context.Context, format string, args ...interface{}) {
+	log.logfContext(ctx, InfoLevel, format, args...)
+}
+
+func (log *Log) WarnfContext(ctx context.Context, format string, args ...interface{}) {
+	log.logfContext(ctx, WarnLevel, format, args...)
+}
+
+func (log *Log) ErrorfContext(ctx context.Context, format string, args ...interface{}) {
+	log.logfContext(ctx, ErrorLevel, format, args...)
+}
+
+func (log *Log) FatalfContext(ctx context.Context, format string, args ...interface{}) {
+	log.logfContext(ctx, FatalLevel, format, args...)
+	log.Exit()
+}
+
+func (log *Log) PanicfContext(ctx context.Context, format string, args ...interface{}) {
+	log.logfContext(ctx, PanicLevel, format, args...)
+}
+
+func (entry *Entry) DebugContext(ctx context.Context, args ...interface{}) {
+	entry.WithContext(ctx).Debug(args...)
+}
+
+func (entry *Entry) InfoContext(ctx context.Context, args ...interface{}) {
+	entry.WithContext(ctx).Info(args...)
+}
+
+func (entry *Entry) WarnContext(ctx context.Context, args ...interface{}) {
+	entry.WithContext(ctx).Warn(args...)
+}
+
+func (entry *Entry) ErrorContext(ctx context.Context, args ...interface{}) {
+	entry.WithContext(ctx).Error(args...)
+}
+
+func (entry *Entry) FatalContext(ctx context.Context, args ...interface{}) {
+	entry.WithContext(ctx).Fatal(args...)
+}
+
+func (entry *Entry) PanicContext(ctx context.Context, args ...interface{}) {
+	entry.WithContext(ctx).Panic(args...)
+}
+
+func (entry *Entry) DebugfContext(ctx context.Context, format string, args ...interface{}) {
+	entry.WithContext(ctx).Debugf(format, args...)
+}
+
+func (entry *Entry) InfofContext(ctx context.Context, format string, args ...interface{}) {
+	entry.WithContext(ctx).Infof(format, args...)
+}
+
+func (entry *Entry) WarnfContext(ctx context.Context, format string, args ...interface{}) {
+	entry.WithContext(ctx).Warnf(format, args...)
+}
+
+func (entry *Entry) ErrorfContext(ctx context.Context, format string, args ...interface{}) {
+	entry.WithContext(ctx).Errorf(format, args...)
+}
+
+func (entry *Entry) FatalfContext(ctx context.Context, format string, args ...interface{}) {
+	entry.WithContext(ctx).Fatalf(format, args...)
+}
+
+func (entry *Entry) PanicfContext(ctx context.Context, format string, args ...interface{}) {
+	entry.WithContext(ctx).Panicf(format, args...)
+}
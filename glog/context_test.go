@@ -0,0 +1,52 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestContextLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newLog(WithOutput(buf), WithContextExtractor(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(traceKey{}).(string); ok {
+			return []Field{{Key: "trace_id", Value: id}}
+		}
+		return nil
+	}))
+	var logger Logger = log
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "abc")
+	logger.InfofContext(ctx, "hello %s", "world")
+	if !strings.Contains(buf.String(), "trace_id= abc _msg= hello world") {
+		t.Fatalf("missing context field: %q", buf.String())
+	}
+
+	// context中的级别覆盖Log的级别
+	buf.Reset()
+	logger.DebugContext(ctx, "hidden")
+	logger.DebugContext(ContextWithLevel(ctx, DebugLevel), "visible")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "visible") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+
+	// 同一个entry多次输出不会重复追加字段
+	buf.Reset()
+	entry := logger.WithContext(ctx).WithField(Field{Key: "k", Value: "v"})
+	entry.Info("one")
+	entry.Info("two")
+	if strings.Count(buf.String(), "trace_id") != 2 || len(entry.Data) != 1 {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	logger.ErrorContext(canceled, "late")
+	if !strings.Contains(buf.String(), FieldKeyCtxErr+"= context canceled") {
+		t.Fatalf("missing ctx error: %q", buf.String())
+	}
+}
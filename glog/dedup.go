@@ -0,0 +1,113 @@
+package glog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 连续重复的日志在窗口期内只输出一次，窗口结束或出现不同的日志时输出重复次数
+type dedup struct {
+	window     time.Duration
+	key        string
+	level      Level
+	count      int
+	last       time.Time
+	active     bool
+	timer      *time.Timer
+	generation uint64
+}
+
+func newDedup(window time.Duration) *dedup {
+	return &dedup{window: window}
+}
+
+// 调用时需要持有log.mu，返回true表示当前日志被合并不需要输出
+func (d *dedup) suppress(entry *Entry) bool {
+	// fatal和panic日志始终输出
+	if entry.Level <= FatalLevel {
+		d.flush(entry.Log)
+		return false
+	}
+
+	key := dedupKey(entry)
+	if d.active && d.key == key {
+		d.count++
+		d.last = entry.Time
+		return true
+	}
+
+	d.flush(entry.Log)
+	d.key = key
+	d.level = entry.Level
+	d.active = true
+	d.generation++
+	generation := d.generation
+	log := entry.Log
+	d.timer = time.AfterFunc(d.window, func() {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		if d.generation == generation {
+			d.flush(log)
+		}
+	})
+
+	return false
+}
+
+// 输出重复次数并结束当前窗口，调用时需要持有log.mu
+func (d *dedup) flush(log *Log) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	count := d.count
+	d.active = false
+	d.key = ""
+	d.count = 0
+	if count == 0 {
+		return
+	}
+
+	entry := &Entry{
+		Log:     log,
+		Time:    d.last,
+		Level:   d.level,
+		Message: fmt.Sprintf("last message repeated %d times", count),
+	}
+	serialized, err := log.Formatter.Format(entry)
+	if err != nil {
+		fmt.Printf("read failed, %v\n", err)
+		return
+	}
+	n, err := writeLevel(log.Out, d.level, serialized)
+	log.stats.bytes.Add(uint64(n))
+	if err != nil {
+		fmt.Printf("failed to write to log, %v\n", err)
+	}
+}
+
+// 比较级别、消息和字段，不比较时间
+func dedupKey(entry *Entry) string {
+	var b strings.Builder
+	b.WriteString(entry.Level.String())
+	b.WriteByte(0)
+	b.WriteString(entry.Message)
+	for _, v := range entry.Data {
+		b.WriteByte(0)
+		b.WriteString(v.Key)
+		b.WriteByte('=')
+		b.WriteString(fmt.Sprint(v.Value))
+	}
+
+	return b.String()
+}
+
+// Flush 输出等待中的重复次数，程序退出前调用
+func (log *Log) Flush() {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if log.dedup != nil {
+		log.dedup.flush(log)
+	}
+}
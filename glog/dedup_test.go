@@ -0,0 +1,63 @@
+package glog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDedup(t *testing.T) {
+	buf := &syncBuffer{}
+	log := newLog(WithOutput(buf), WithDedup(50*time.Millisecond))
+
+	for i := 0; i < 5; i++ {
+		log.WithField(Field{Key: "url", Value: "/a"}).Error("request failed")
+	}
+	log.Info("done")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[1], "last message repeated 4 times") || !strings.HasSuffix(lines[2], "done") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	// 窗口结束后输出重复次数
+	log.Info("done")
+	log.Info("done")
+	repeated := func() bool {
+		return strings.HasSuffix(strings.TrimSpace(buf.String()), "last message repeated 2 times")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !repeated() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !repeated() {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	// Flush立即输出重复次数，不需要等待窗口结束
+	log = newLog(WithOutput(buf), WithDedup(time.Hour))
+	log.Info("again")
+	log.Info("again")
+	log.Flush()
+	if !strings.HasSuffix(strings.TrimSpace(buf.String()), "last message repeated 1 times") {
+		t.Fatalf("unexpected output after flush:\n%s", buf.String())
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
-	"strings"
 	"time"
 )
 
@@ -20,6 +19,8 @@ type Entry struct {
 	Buffer  *bytes.Buffer
 	Message string
 	err     string
+	// 在第一个非log调用函数之上额外跳过的调用层数
+	callerSkip int
 }
 
 func NewEntry(log *Log) *Entry {
@@ -49,7 +50,7 @@ func (entry *Entry) WithTime(t time.Time) *Entry {
 	for k, v := range entry.Data {
 		dataCopy[k] = v
 	}
-	return &Entry{Ctx: entry.Ctx, Log: entry.Log, Data: dataCopy, Time: t, err: entry.err}
+	return &Entry{Ctx: entry.Ctx, Log: entry.Log, Data: dataCopy, Time: t, err: entry.err, callerSkip: entry.callerSkip}
 }
 
 func (entry *Entry) WithContext(ctx context.Context) *Entry {
@@ -57,7 +58,7 @@ func (entry *Entry) WithContext(ctx context.Context) *Entry {
 	for k, v := range entry.Data {
 		dataCopy[k] = v
 	}
-	return &Entry{Ctx: ctx, Log: entry.Log, Data: dataCopy, Time: entry.Time, err: entry.err}
+	return &Entry{Ctx: ctx, Log: entry.Log, Data: dataCopy, Time: entry.Time, err: entry.err, callerSkip: entry.callerSkip}
 }
 
 func (entry *Entry) WithField(field Field) *Entry {
@@ -89,55 +90,14 @@ func (entry *Entry) WithFields(fields []Field) *Entry {
 			data[k] = v
 		}
 	}
-	return &Entry{Ctx: entry.Ctx, Log: entry.Log, Data: data, Time: entry.Time, err: fieldErr}
+	return &Entry{Ctx: entry.Ctx, Log: entry.Log, Data: data, Time: entry.Time, err: fieldErr, callerSkip: entry.callerSkip}
 }
 
-func getPackageName(f string) string {
-	for {
-		lastPeriod := strings.LastIndex(f, ".")
-		lastSlash := strings.LastIndex(f, "/")
-		if lastPeriod > lastSlash {
-			f = f[:lastPeriod]
-		} else {
-			break
-		}
-	}
-
-	return f
-}
-
-// 检索第一个非log调用函数的名称
-func getCaller() *runtime.Frame {
-	callerInitOnce.Do(func() {
-		pcs := make([]uintptr, maximumCallerDepth)
-		_ = runtime.Callers(0, pcs)
-
-		// 动态获取包名和最小调用深度
-		for i := 0; i < maximumCallerDepth; i++ {
-			funcName := runtime.FuncForPC(pcs[i]).Name()
-			if strings.Contains(funcName, "getCaller") {
-				logPackage = getPackageName(funcName)
-				break
-			}
-		}
-
-		minimumCallerDepth = knownLogFrames
-	})
-
-	pcs := make([]uintptr, maximumCallerDepth)
-	depth := runtime.Callers(minimumCallerDepth, pcs)
-	frames := runtime.CallersFrames(pcs[:depth])
-
-	for f, again := frames.Next(); again; f, again = frames.Next() {
-		pkg := getPackageName(f.Function)
-
-		// 如果调用者不是这个包的一部分，就完成了
-		if pkg != logPackage {
-			return &f
-		}
-	}
-
-	return nil
+// AddCallerSkip 返回额外跳过n层调用的entry，用于封装日志方法时报告真正的调用位置
+func (entry *Entry) AddCallerSkip(n int) *Entry {
+	e := entry.WithFields(nil)
+	e.callerSkip += n
+	return e
 }
 
 func (entry *Entry) Debug(args ...interface{}) {
@@ -208,11 +168,18 @@ func (entry *Entry) loadLog(level Level, msg string) {
 
 	entry.Log.mu.Lock()
 	reportCaller := entry.Log.ReportCaller
+	callerSkip := entry.Log.CallerSkip
 	bufPool := entry.getBufferPool()
 	entry.Log.mu.Unlock()
 
 	if reportCaller {
-		entry.Caller = getCaller()
+		entry.Caller = entry.Log.getCaller(callerSkip + entry.callerSkip)
+	}
+
+	if fields := entry.Log.contextFields(entry.Ctx); len(fields) > 0 {
+		data := entry.Data
+		entry.Data = append(data[:len(data):len(data)], fields...)
+		defer func() { entry.Data = data }()
 	}
 
 	buffer := bufPool.Get()
@@ -224,6 +191,11 @@ func (entry *Entry) loadLog(level Level, msg string) {
 	buffer.Reset()
 	entry.Buffer = buffer
 
+	// 出错时先输出同一个context中缓冲的日志
+	if level <= ErrorLevel {
+		FlushBuffered(entry.Ctx)
+	}
+
 	entry.write()
 
 	entry.Buffer = nil
@@ -236,26 +208,46 @@ func (entry *Entry) loadLog(level Level, msg string) {
 func (entry *Entry) write() {
 	entry.Log.mu.Lock()
 	defer entry.Log.mu.Unlock()
+	stats := &entry.Log.stats
+	if entry.Log.sampler != nil && entry.Log.sampler.drop(entry) {
+		stats.sampled.Add(1)
+		return
+	}
+	if entry.Log.dedup != nil && entry.Log.dedup.suppress(entry) {
+		return
+	}
+	stats.addEntry(entry.Level)
 	// read log bytes
+	start := time.Now()
 	serialized, err := entry.Log.Formatter.Format(entry)
+	stats.formatNanos.Add(uint64(time.Since(start)))
 	if err != nil {
+		stats.dropped.Add(1)
 		fmt.Printf("read failed, %v\n", err)
 		return
 	}
-	_, err = entry.Log.Out.Write(serialized)
+	start = time.Now()
+	n, err := writeLevel(entry.Log.Out, entry.Level, serialized)
+	stats.writeNanos.Add(uint64(time.Since(start)))
+	stats.bytes.Add(uint64(n))
 	if err != nil {
+		stats.dropped.Add(1)
 		fmt.Printf("failed to write to log, %v\n", err)
 	}
+
+	entry.fireHooks()
 }
 
 func (entry *Entry) log(level Level, args ...interface{}) {
-	if entry.Log.IsLevelEnabled(level) {
+	if entry.Log.IsLevelEnabledContext(entry.Ctx, level) {
 		entry.loadLog(level, fmt.Sprint(args...))
+	} else if rb := ringBufferFromContext(entry.Ctx); rb != nil {
+		entry.buffer(rb, level, fmt.Sprint(args...))
 	}
 }
 
 func (entry *Entry) logf(level Level, format string, args ...interface{}) {
-	if entry.Log.IsLevelEnabled(level) {
+	if entry.Log.shouldLog(entry.Ctx, level) {
 		entry.log(level, fmt.Sprintf(format, args...))
 	}
 }
@@ -0,0 +1,88 @@
+package glog
+
+import (
+	"context"
+	"time"
+)
+
+// 包级别的日志函数使用New返回的全局Log
+
+func std() *Log {
+	return New()
+}
+
+func WithField(field Field) *Entry {
+	return std().WithField(field)
+}
+
+func WithFields(fields []Field) *Entry {
+	return std().WithFields(fields)
+}
+
+func WithError(err error) *Entry {
+	return std().WithError(err)
+}
+
+func WithContext(ctx context.Context) *Entry {
+	return std().WithContext(ctx)
+}
+
+func WithTime(t time.Time) *Entry {
+	return std().WithTime(t)
+}
+
+func Debug(args ...interface{}) {
+	std().Debug(args...)
+}
+
+func Info(args ...interface{}) {
+	std().Info(args...)
+}
+
+func Warn(args ...interface{}) {
+	std().Warn(args...)
+}
+
+func Warning(args ...interface{}) {
+	std().Warning(args...)
+}
+
+func Error(args ...interface{}) {
+	std().Error(args...)
+}
+
+func Fatal(args ...interface{}) {
+	std().Fatal(args...)
+}
+
+func Panic(args ...interface{}) {
+	std().Panic(args...)
+}
+
+func Debugf(format string, args ...interface{}) {
+	std().Debugf(format, args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	std().Infof(format, args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	std().Warnf(format, args...)
+}
+
+func Warningf(format string, args ...interface{}) {
+	std().Warningf(format, args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	std().Errorf(format, args...)
+}
+
+func Fatalf(format string, args ...interface{}) {
+	std().Fatalf(format, args...)
+}
+
+func Panicf(format string, args ...interface{}) {
+	std().Panicf(format, args...)
+}
@@ -13,14 +13,21 @@ var log *Log
 var once sync.Once
 
 type Log struct {
-	Out          io.Writer
-	Formatter    Formatter
-	Level        Level
-	entryPool    sync.Pool
-	ExitFunc     exitFunc
-	BufferPool   BufferPool
-	ReportCaller bool // 是否标记调用信息
-	mu           sync.Mutex
+	Out               io.Writer
+	Formatter         Formatter
+	Level             Level
+	entryPool         sync.Pool
+	ExitFunc          exitFunc
+	BufferPool        BufferPool
+	ReportCaller      bool               // 是否标记调用信息
+	CallerSkip        int                // 在第一个非log调用函数之上额外跳过的调用层数
+	ContextExtractors []ContextExtractor // 从context中提取日志字段
+	Hooks             []Hook
+	mu                sync.Mutex
+	stats             logStats
+	dedup             *dedup
+	sampler           *sampler
+	frames            sync.Map // pc -> []runtime.Frame
 }
 
 func New(opts ...Option) *Log {
@@ -55,6 +62,7 @@ func (log *Log) newEntry() *Entry {
 
 func (log *Log) putEntry(entry *Entry) {
 	entry.Data = []Field{}
+	entry.Ctx = nil
 	log.entryPool.Put(entry)
 }
 
@@ -205,6 +213,12 @@ func (log *Log) SetReportCaller(reportCaller bool) {
 	log.ReportCaller = reportCaller
 }
 
+func (log *Log) SetCallerSkip(skip int) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.CallerSkip = skip
+}
+
 func GetLog() *Log {
 	return log
 }
@@ -0,0 +1,31 @@
+package glog
+
+import "fmt"
+
+// Hook 日志写入后回调，Fire中不能再使用同一个Log输出日志
+// entry在回调结束后会被复用，需要保留的数据应当复制
+type Hook interface {
+	Levels() []Level
+	Fire(entry *Entry) error
+}
+
+func (log *Log) AddHook(hook Hook) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.Hooks = append(log.Hooks, hook)
+}
+
+// 调用时需要持有log.mu
+func (entry *Entry) fireHooks() {
+	for _, hook := range entry.Log.Hooks {
+		for _, level := range hook.Levels() {
+			if level != entry.Level {
+				continue
+			}
+			if err := hook.Fire(entry); err != nil {
+				fmt.Printf("failed to fire hook, %v\n", err)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package glog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type JSONFormatter struct {
+	TimestampFormat string // 时间格式，默认为 2006-01-02 15:04:05
+}
+
+func (jf *JSONFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+5)
+	for _, v := range entry.Data {
+		switch val := v.Value.(type) {
+		case error:
+			// error类型通常没有导出字段，直接序列化会得到{}
+			data[v.Key] = val.Error()
+		default:
+			data[v.Key] = val
+		}
+	}
+
+	timestampFormat := jf.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = defaultTimestampFormat
+	}
+	data[FieldKeyTime] = entry.Time.Format(timestampFormat)
+	data[FieldKeyLevel] = entry.Level.String()
+	data[FieldKeyMsg] = entry.Message
+	if entry.Caller != nil {
+		data[FieldKeyFunc] = entry.Caller.Function
+		data[FieldKeyFile] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+
+	return b.Bytes(), nil
+}
@@ -1,7 +1,6 @@
 package glog
 
 import (
-	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
@@ -31,16 +30,20 @@ var _ io.WriteCloser = (*LogFile)(nil)
 
 // LogFile 如果MaxBackups和MaxAge都为0，则不会删除旧的日志文件
 type LogFile struct {
-	Filename   string
-	MaxSize    int  // 日志文件获取之前的最大大小(以兆字节为单位)，默认为100兆字节
-	MaxAge     int  // 根据旧日志文件保留的最大天数
-	MaxBackups int  // 保留的旧日志文件的最大数量，默认是保留所有旧的日志文件
-	Compress   bool // 压缩确定是否应压缩轮转的日志文件，使用gzip，默认不进行压缩
-	size       int64
-	file       *os.File
-	mu         sync.Mutex
-	millCh     chan bool
-	startMill  sync.Once
+	Filename            string
+	MaxSize             int        // 日志文件获取之前的最大大小(以兆字节为单位)，默认为100兆字节
+	MaxAge              int        // 根据旧日志文件保留的最大天数
+	MaxBackups          int        // 保留的旧日志文件的最大数量，默认是保留所有旧的日志文件
+	Compress            bool       // 压缩确定是否应压缩轮转的日志文件，默认不进行压缩
+	Compressor          Compressor // 压缩算法，默认使用gzip默认压缩级别
+	CompressDelay       int        // 最近的CompressDelay个备份文件不压缩，便于tail等工具继续读取
+	CompressConcurrency int        // 同时压缩的最大文件数，默认为1
+	size                int64
+	file                *os.File
+	mu                  sync.Mutex
+	millCh              chan bool
+	millDone            chan struct{}
+	stats               fileStats
 }
 
 func (lf *LogFile) Write(p []byte) (n int, err error) {
@@ -56,26 +59,41 @@ func (lf *LogFile) Write(p []byte) (n int, err error) {
 
 	if lf.file == nil {
 		if err = lf.openExistingOrNew(len(p)); err != nil {
+			lf.stats.writeErrors.Add(1)
 			return 0, err
 		}
 	}
 
 	if lf.size+writeLen > lf.max() {
 		if err := lf.rotate(); err != nil {
+			lf.stats.writeErrors.Add(1)
 			return 0, err
 		}
 	}
 
 	n, err = lf.file.Write(p)
 	lf.size += int64(n)
+	lf.stats.bytes.Add(uint64(n))
+	if err != nil {
+		lf.stats.writeErrors.Add(1)
+	}
 
 	return n, err
 }
 
+// Close 关闭当前日志文件，并等待正在进行的压缩和清理完成；关闭后再次写入会重新打开文件
 func (lf *LogFile) Close() error {
 	lf.mu.Lock()
-	defer lf.mu.Unlock()
-	return lf.close()
+	err := lf.close()
+	millCh, millDone := lf.millCh, lf.millDone
+	lf.millCh, lf.millDone = nil, nil
+	lf.mu.Unlock()
+
+	if millCh != nil {
+		close(millCh)
+		<-millDone
+	}
+	return err
 }
 
 func (lf *LogFile) close() error {
@@ -87,6 +105,16 @@ func (lf *LogFile) close() error {
 	return err
 }
 
+// Sync 将当前日志文件的内容刷新到磁盘
+func (lf *LogFile) Sync() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.file == nil {
+		return nil
+	}
+	return lf.file.Sync()
+}
+
 func (lf *LogFile) Rotate() error {
 	lf.mu.Lock()
 	defer lf.mu.Unlock()
@@ -100,6 +128,7 @@ func (lf *LogFile) rotate() error {
 	if err := lf.openNew(); err != nil {
 		return err
 	}
+	lf.stats.rotations.Add(1)
 	lf.mill()
 	return nil
 }
@@ -194,10 +223,7 @@ func (lf *LogFile) millRunOnce() error {
 		var remaining []logInfo
 		for _, f := range files {
 			// 只统计未压缩的日志文件或压缩日志文件
-			fn := f.Name()
-			if strings.HasSuffix(fn, compressSuffix) {
-				fn = fn[:len(fn)-len(compressSuffix)]
-			}
+			fn, _ := trimCompressSuffix(f.Name(), lf.compressors())
 			preserved[fn] = true
 
 			if len(preserved) > lf.MaxBackups {
@@ -224,8 +250,11 @@ func (lf *LogFile) millRunOnce() error {
 	}
 
 	if lf.Compress {
-		for _, f := range files {
-			if !strings.HasSuffix(f.Name(), compressSuffix) {
+		for i, f := range files {
+			if i < lf.CompressDelay {
+				continue
+			}
+			if _, ok := compressorFor(f.Name(), lf.compressors()); !ok {
 				compress = append(compress, f)
 			}
 		}
@@ -237,29 +266,82 @@ func (lf *LogFile) millRunOnce() error {
 			err = errRemove
 		}
 	}
-	for _, f := range compress {
+	if errCompress := lf.compressFiles(compress); err == nil {
+		err = errCompress
+	}
+
+	return err
+}
+
+// 最多同时压缩CompressConcurrency个文件，返回第一个错误
+func (lf *LogFile) compressFiles(files []logInfo) error {
+	concurrency := lf.CompressConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	compressor := lf.compressor()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		err error
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, f := range files {
 		fn := filepath.Join(lf.dir(), f.Name())
-		errCompress := compressLogFile(fn, fn+compressSuffix)
-		if err == nil && errCompress != nil {
-			err = errCompress
-		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errCompress := compressLogFile(fn, fn+compressor.Suffix(), compressor)
+			lf.stats.compressions.Add(1)
+			if errCompress != nil {
+				lf.stats.compressErrors.Add(1)
+				mu.Lock()
+				if err == nil {
+					err = errCompress
+				}
+				mu.Unlock()
+			}
+		}()
 	}
+	wg.Wait()
 
 	return err
 }
 
-func (lf *LogFile) millRun() {
-	for range lf.millCh {
+func (lf *LogFile) compressor() Compressor {
+	if lf.Compressor != nil {
+		return lf.Compressor
+	}
+	return &GzipCompressor{}
+}
+
+// 返回可以识别的压缩算法，包括配置的和已注册的
+func (lf *LogFile) compressors() []Compressor {
+	return getCompressors(lf.Compressor)
+}
+
+func (lf *LogFile) millRun(millCh <-chan bool, done chan<- struct{}) {
+	defer close(done)
+	for range millCh {
+		start := time.Now()
 		_ = lf.millRunOnce()
+		lf.stats.millRuns.Add(1)
+		lf.stats.millNanos.Add(uint64(time.Since(start)))
 	}
 }
 
 // 执行旋转后压缩并删除过时的日志文件
 func (lf *LogFile) mill() {
-	lf.startMill.Do(func() {
+	if lf.millCh == nil {
 		lf.millCh = make(chan bool, 1)
-		go lf.millRun()
-	})
+		lf.millDone = make(chan struct{})
+		go lf.millRun(lf.millCh, lf.millDone)
+	}
 	select {
 	case lf.millCh <- true:
 	default:
@@ -275,6 +357,7 @@ func (lf *LogFile) oldLogFiles() ([]logInfo, error) {
 	var logFiles []logInfo
 
 	prefix, ext := lf.prefixAndExt()
+	list := lf.compressors()
 
 	for _, f := range files {
 		if f.IsDir() {
@@ -284,9 +367,11 @@ func (lf *LogFile) oldLogFiles() ([]logInfo, error) {
 			logFiles = append(logFiles, logInfo{t, f})
 			continue
 		}
-		if t, err := lf.timeFromName(f.Name(), prefix, ext+compressSuffix); err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
-			continue
+		for _, c := range list {
+			if t, err := lf.timeFromName(f.Name(), prefix, ext+c.Suffix()); err == nil {
+				logFiles = append(logFiles, logInfo{t, f})
+				break
+			}
 		}
 	}
 
@@ -295,6 +380,38 @@ func (lf *LogFile) oldLogFiles() ([]logInfo, error) {
 	return logFiles, nil
 }
 
+// 返回全部日志文件路径(备份文件在前，当前文件在最后)，按时间从旧到新排序
+func (lf *LogFile) logFiles() ([]string, error) {
+	var names []string
+
+	if _, err := osStat(lf.dir()); os.IsNotExist(err) {
+		return names, nil
+	}
+	files, err := lf.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool, len(files))
+	for _, f := range files {
+		exists[f.Name()] = true
+	}
+	list := lf.compressors()
+	for i := len(files) - 1; i >= 0; i-- {
+		fn := files[i].Name()
+		// 压缩未完成时会同时存在原文件和压缩文件，以原文件为准
+		if origin, ok := trimCompressSuffix(fn, list); ok && exists[origin] {
+			continue
+		}
+		names = append(names, filepath.Join(lf.dir(), fn))
+	}
+	if _, err := osStat(lf.filename()); err == nil {
+		names = append(names, lf.filename())
+	}
+
+	return names, nil
+}
+
 func (lf *LogFile) timeFromName(filename, prefix, ext string) (time.Time, error) {
 	if !strings.HasPrefix(filename, prefix) {
 		return time.Time{}, errors.New("mismatched prefix")
@@ -324,7 +441,7 @@ func (lf *LogFile) prefixAndExt() (prefix, ext string) {
 	return prefix, ext
 }
 
-func compressLogFile(src, dst string) (err error) {
+func compressLogFile(src, dst string, compressor Compressor) (err error) {
 	f, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %v", err)
@@ -346,8 +463,6 @@ func compressLogFile(src, dst string) (err error) {
 	}
 	defer gzf.Close()
 
-	gz := gzip.NewWriter(gzf)
-
 	defer func() {
 		if err != nil {
 			_ = os.Remove(dst)
@@ -355,6 +470,10 @@ func compressLogFile(src, dst string) (err error) {
 		}
 	}()
 
+	gz, err := compressor.NewWriter(gzf)
+	if err != nil {
+		return err
+	}
 	if _, err := io.Copy(gz, f); err != nil {
 		return err
 	}
@@ -375,6 +494,38 @@ func compressLogFile(src, dst string) (err error) {
 	return nil
 }
 
+// 打开日志文件用于读取，压缩文件按后缀自动解压，extra为已注册之外的压缩算法
+func openLogFile(name string, extra ...Compressor) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := compressorFor(name, getCompressors(extra...))
+	if !ok {
+		return f, nil
+	}
+	r, err := c.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to open compressed log file: %v", err)
+	}
+
+	return &compressedReadCloser{ReadCloser: r, file: f}, nil
+}
+
+type compressedReadCloser struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (c *compressedReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	if errClose := c.file.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
 //func chown(_ string, _ os.FileInfo) error {
 //	return nil
 //}
@@ -0,0 +1,460 @@
+package glog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultPollInterval = 500 * time.Millisecond
+
+var auditUnescaper = strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\r", "\r")
+
+// Record 从日志文件中解析出的一条日志
+type Record struct {
+	File    string
+	Time    time.Time
+	Level   Level
+	Data    []Field
+	Message string
+	Raw     string
+}
+
+// Field 返回自定义字段的值
+func (r *Record) Field(key string) (string, bool) {
+	for _, v := range r.Data {
+		if v.Key == key {
+			return fmt.Sprint(v.Value), true
+		}
+	}
+	return "", false
+}
+
+// ParseRecord 解析一行TextFormatter或JSONFormatter格式的日志，兼容审计日志的行格式
+func ParseRecord(line string) (*Record, error) {
+	return ParseRecordWithFormat(line, "")
+}
+
+// ParseRecordWithFormat 与ParseRecord相同，JSON日志的时间使用JSONFormatter.TimestampFormat指定的timestampFormat解析
+func ParseRecordWithFormat(line, timestampFormat string) (*Record, error) {
+	raw := line
+	if strings.HasPrefix(line, FieldKeySeq+"= ") {
+		al, err := parseAuditLine(line)
+		if err != nil {
+			return nil, err
+		}
+		line = auditUnescaper.Replace(al.content)
+	}
+
+	if strings.HasPrefix(line, "{") {
+		return parseJSONRecord(line, raw, timestampFormat)
+	}
+
+	if len(line) < len(defaultTimestampFormat) {
+		return nil, errors.New("malformed log line")
+	}
+	t, err := time.ParseInLocation(defaultTimestampFormat, line[:len(defaultTimestampFormat)], time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("malformed log time: %v", err)
+	}
+
+	rest := line[len(defaultTimestampFormat):]
+	if !strings.HasPrefix(rest, " [") {
+		return nil, errors.New("malformed log level")
+	}
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return nil, errors.New("malformed log level")
+	}
+	level, err := ParseLevel(rest[2:end])
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{Time: t, Level: level, Raw: raw}
+	rest = rest[end+1:]
+	if i := strings.Index(rest, " "+FieldKeyMsg+"= "); i >= 0 {
+		record.Message = rest[i+len(FieldKeyMsg)+3:]
+		rest = rest[:i]
+	}
+	record.Data = parseRecordFields(rest)
+
+	return record, nil
+}
+
+// 解析JSONFormatter输出的日志，timestampFormat为空时使用默认的时间格式
+func parseJSONRecord(line, raw, timestampFormat string) (*Record, error) {
+	if timestampFormat == "" {
+		timestampFormat = defaultTimestampFormat
+	}
+	data := make(map[string]interface{})
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("malformed json log line: %v", err)
+	}
+
+	ts, _ := data[FieldKeyTime].(string)
+	t, err := time.ParseInLocation(timestampFormat, ts, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("malformed log time: %v", err)
+	}
+	lvl, _ := data[FieldKeyLevel].(string)
+	level, err := ParseLevel(lvl)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{Time: t, Level: level, Raw: raw}
+	record.Message, _ = data[FieldKeyMsg].(string)
+	delete(data, FieldKeyTime)
+	delete(data, FieldKeyLevel)
+	delete(data, FieldKeyMsg)
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		record.Data = append(record.Data, Field{Key: k, Value: data[k]})
+	}
+
+	return record, nil
+}
+
+// 字段格式为 " key= value"，value中可能包含空格
+func parseRecordFields(s string) []Field {
+	var (
+		fields []Field
+		cur    *Field
+	)
+	tokens := strings.Split(strings.TrimPrefix(s, " "), " ")
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if strings.HasSuffix(token, "=") && len(token) > 1 {
+			field := Field{Key: token[:len(token)-1], Value: ""}
+			if i+1 < len(tokens) {
+				i++
+				field.Value = tokens[i]
+			}
+			fields = append(fields, field)
+			cur = &fields[len(fields)-1]
+			continue
+		}
+		if cur != nil {
+			cur.Value = cur.Value.(string) + " " + token
+		}
+	}
+
+	return fields
+}
+
+// Query 日志查询条件，零值表示不过滤
+type Query struct {
+	Since   time.Time
+	Until   time.Time
+	Levels  []Level
+	Fields  map[string]string
+	Message *regexp.Regexp
+}
+
+func (q *Query) Match(r *Record) bool {
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Time.After(q.Until) {
+		return false
+	}
+	if len(q.Levels) > 0 {
+		matched := false
+		for _, level := range q.Levels {
+			if level == r.Level {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for key, val := range q.Fields {
+		if v, ok := r.Field(key); !ok || v != val {
+			return false
+		}
+	}
+	if q.Message != nil && !q.Message.MatchString(r.Message) {
+		return false
+	}
+
+	return true
+}
+
+// LogReader 按时间顺序读取LogFile的当前文件和所有备份文件，压缩的备份文件会自动解压
+type LogReader struct {
+	file            *LogFile
+	PollInterval    time.Duration // 跟踪模式下检查新日志的间隔，默认500毫秒
+	TimestampFormat string        // JSON日志的时间格式，与JSONFormatter.TimestampFormat一致，默认为 2006-01-02 15:04:05
+}
+
+func NewLogReader(lf *LogFile) *LogReader {
+	return &LogReader{
+		file:         lf,
+		PollInterval: defaultPollInterval,
+	}
+}
+
+// Read 读取全部匹配的日志，fn返回错误时停止读取
+func (lr *LogReader) Read(ctx context.Context, q Query, fn func(*Record) error) error {
+	files, err := lr.files(q)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := lr.readFile(ctx, name, q, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Follow 读取全部匹配的日志后继续等待新写入的日志，日志轮转后会自动打开新文件，直到ctx结束；
+// 多行日志可能分多次写入，读到下一条日志或者等待PollInterval没有新内容后才返回最后一条日志
+func (lr *LogReader) Follow(ctx context.Context, q Query, fn func(*Record) error) error {
+	files, err := lr.files(q)
+	if err != nil {
+		return err
+	}
+	current := lr.file.filename()
+	for _, name := range files {
+		if name == current {
+			continue
+		}
+		if err := lr.readFile(ctx, name, q, fn); err != nil {
+			return err
+		}
+	}
+
+	var (
+		f        *os.File
+		r        *bufio.Reader
+		partial  string
+		draining bool
+		idle     bool // 上次等待后没有读到新内容
+	)
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+	sc := lr.newRecordScanner(current, q, fn)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if f == nil {
+			f, err = os.Open(current)
+			if os.IsNotExist(err) {
+				if err := lr.wait(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			r = bufio.NewReader(f)
+		}
+
+		s, err := r.ReadString('\n')
+		if s != "" {
+			idle = false
+		}
+		if err == nil {
+			line := partial + strings.TrimSuffix(s, "\n")
+			partial = ""
+			if err := sc.push(line); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		// 未写完的行，下次继续读取
+		partial += s
+
+		if draining {
+			// 已读完轮转前的文件，打开新文件
+			if partial != "" {
+				if err := sc.push(partial); err != nil {
+					return err
+				}
+				partial = ""
+			}
+			if err := sc.flush(); err != nil {
+				return err
+			}
+			_ = f.Close()
+			f, draining = nil, false
+			continue
+		}
+		if lr.rotated(f, current) {
+			draining = true
+			continue
+		}
+		// 等待一个PollInterval后仍然没有新内容，最后一条日志已经写完
+		if idle && partial == "" {
+			if err := sc.flush(); err != nil {
+				return err
+			}
+		}
+		if err := lr.wait(ctx); err != nil {
+			return err
+		}
+		idle = true
+	}
+}
+
+func (lr *LogReader) wait(ctx context.Context) error {
+	interval := lr.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// 判断打开的文件是否已被轮转或截断
+func (lr *LogReader) rotated(f *os.File, name string) bool {
+	info, err := os.Stat(name)
+	if err != nil {
+		return true
+	}
+	cur, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	if !os.SameFile(info, cur) {
+		return true
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	return err == nil && info.Size() < pos
+}
+
+// 返回需要读取的文件，备份文件名中的时间是轮转时间，早于Since的备份文件不需要读取
+func (lr *LogReader) files(q Query) ([]string, error) {
+	files, err := lr.file.logFiles()
+	if err != nil {
+		return nil, err
+	}
+	if q.Since.IsZero() {
+		return files, nil
+	}
+
+	prefix, ext := lr.file.prefixAndExt()
+	list := lr.file.compressors()
+	var names []string
+	for _, name := range files {
+		base, _ := trimCompressSuffix(filepath.Base(name), list)
+		if strings.HasPrefix(base, prefix) && strings.HasSuffix(base, ext) {
+			ts := base[len(prefix) : len(base)-len(ext)]
+			if t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local); err == nil && t.Before(q.Since) {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (lr *LogReader) readFile(ctx context.Context, name string, q Query, fn func(*Record) error) error {
+	rc, err := openLogFile(name, lr.file.Compressor)
+	if os.IsNotExist(err) {
+		// 读取过程中可能被清理或压缩
+		name += lr.file.compressor().Suffix()
+		if rc, err = openLogFile(name, lr.file.Compressor); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	sc := lr.newRecordScanner(name, q, fn)
+	r := bufio.NewReader(rc)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if s != "" {
+			if errPush := sc.push(strings.TrimSuffix(s, "\n")); errPush != nil {
+				return errPush
+			}
+		}
+		if err == io.EOF {
+			return sc.flush()
+		}
+	}
+}
+
+// 无法解析的行作为上一条日志消息的续行
+type recordScanner struct {
+	file            string
+	timestampFormat string
+	query           Query
+	fn              func(*Record) error
+	pending         *Record
+}
+
+func (lr *LogReader) newRecordScanner(file string, q Query, fn func(*Record) error) *recordScanner {
+	return &recordScanner{file: file, timestampFormat: lr.TimestampFormat, query: q, fn: fn}
+}
+
+func (sc *recordScanner) push(line string) error {
+	record, err := ParseRecordWithFormat(line, sc.timestampFormat)
+	if err != nil {
+		if sc.pending != nil {
+			sc.pending.Message += "\n" + line
+			sc.pending.Raw += "\n" + line
+		}
+		return nil
+	}
+	if err := sc.flush(); err != nil {
+		return err
+	}
+	record.File = sc.file
+	sc.pending = record
+
+	return nil
+}
+
+func (sc *recordScanner) flush() error {
+	record := sc.pending
+	sc.pending = nil
+	if record == nil || !sc.query.Match(record) {
+		return nil
+	}
+	return sc.fn(record)
+}
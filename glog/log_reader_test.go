@@ -0,0 +1,151 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogReader(t *testing.T) {
+	lf := &LogFile{Filename: filepath.Join(t.TempDir(), "app.log"), Compress: true}
+	log := newLog(WithOutput(lf), WithLevel(DebugLevel))
+	log.WithField(Field{Key: "user", Value: "admin"}).Info("login ok")
+	log.Error("first\nsecond")
+	if err := lf.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	// Close等待后台压缩完成
+	if err := lf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	log.WithField(Field{Key: "user", Value: "guest"}).Warn("login failed")
+	log.Debug("debug")
+
+	var records []*Record
+	collect := func(r *Record) error {
+		records = append(records, r)
+		return nil
+	}
+	reader := NewLogReader(lf)
+	if err := reader.Read(context.Background(), Query{}, collect); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+	if filepath.Ext(records[0].File) != compressSuffix {
+		t.Fatalf("first record should come from the compressed backup, got %s", records[0].File)
+	}
+	if records[1].Message != "first\nsecond" {
+		t.Fatalf("unexpected multi-line message %q", records[1].Message)
+	}
+
+	records = nil
+	q := Query{
+		Levels:  []Level{InfoLevel, WarnLevel},
+		Fields:  map[string]string{"user": "guest"},
+		Message: regexp.MustCompile("^login"),
+	}
+	if err := reader.Read(context.Background(), q, collect); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Message != "login failed" || records[0].Level != WarnLevel {
+		t.Fatalf("unexpected records %+v", records)
+	}
+}
+
+func TestLogReaderFollow(t *testing.T) {
+	lf := &LogFile{Filename: filepath.Join(t.TempDir(), "app.log")}
+	log := newLog(WithOutput(lf))
+	log.Info("before")
+
+	reader := NewLogReader(lf)
+	reader.PollInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan string, 10)
+	go func() {
+		_ = reader.Follow(ctx, Query{}, func(r *Record) error {
+			ch <- r.Message
+			return nil
+		})
+	}()
+
+	next := func() string {
+		select {
+		case msg := <-ch:
+			return msg
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for record")
+			return ""
+		}
+	}
+	if msg := next(); msg != "before" {
+		t.Fatalf("got %q", msg)
+	}
+	log.Info("appended")
+	if msg := next(); msg != "appended" {
+		t.Fatalf("got %q", msg)
+	}
+	if err := lf.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	log.Info("rotated")
+	if msg := next(); msg != "rotated" {
+		t.Fatalf("got %q", msg)
+	}
+}
+
+func TestLogReaderFollowMultiLine(t *testing.T) {
+	lf := &LogFile{Filename: filepath.Join(t.TempDir(), "app.log")}
+	log := newLog(WithOutput(lf))
+
+	reader := NewLogReader(lf)
+	reader.PollInterval = 200 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch := make(chan string, 10)
+	go func() {
+		_ = reader.Follow(ctx, Query{}, func(r *Record) error {
+			ch <- r.Message
+			return nil
+		})
+	}()
+
+	// 多行日志分两次写入，读到第一部分后不能立即返回
+	log.Info("first")
+	time.Sleep(20 * time.Millisecond)
+	if _, err := lf.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-ch:
+		if msg != "first\nsecond" {
+			t.Fatalf("got %q", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for record")
+	}
+}
+
+func TestParseRecordWithFormat(t *testing.T) {
+	const layout = time.RFC3339Nano
+	var buf bytes.Buffer
+	log := newLog(WithOutput(&buf), WithFormatter(&JSONFormatter{TimestampFormat: layout}))
+	log.Info("custom time")
+
+	line := strings.TrimSpace(buf.String())
+	if _, err := ParseRecord(line); err == nil {
+		t.Fatal("expected error with default format")
+	}
+	r, err := ParseRecordWithFormat(line, layout)
+	if err != nil || r.Message != "custom time" || r.Time.IsZero() {
+		t.Fatalf("record = %+v, err = %v", r, err)
+	}
+}
@@ -1,9 +1,22 @@
 package glog
 
+import (
+	"context"
+	"time"
+)
+
+// 确保Log和Entry始终实现 Logger
+var (
+	_ Logger = (*Log)(nil)
+	_ Logger = (*Entry)(nil)
+)
+
 type Logger interface {
 	WithField(field Field) *Entry
 	WithFields(fields []Field) *Entry
 	WithError(err error) *Entry
+	WithContext(ctx context.Context) *Entry
+	WithTime(t time.Time) *Entry
 
 	Debug(args ...interface{})
 	Info(args ...interface{})
@@ -20,4 +33,18 @@ type Logger interface {
 	Errorf(format string, args ...interface{})
 	Fatalf(format string, args ...interface{})
 	Panicf(format string, args ...interface{})
+
+	DebugContext(ctx context.Context, args ...interface{})
+	InfoContext(ctx context.Context, args ...interface{})
+	WarnContext(ctx context.Context, args ...interface{})
+	ErrorContext(ctx context.Context, args ...interface{})
+	FatalContext(ctx context.Context, args ...interface{})
+	PanicContext(ctx context.Context, args ...interface{})
+
+	DebugfContext(ctx context.Context, format string, args ...interface{})
+	InfofContext(ctx context.Context, format string, args ...interface{})
+	WarnfContext(ctx context.Context, format string, args ...interface{})
+	ErrorfContext(ctx context.Context, format string, args ...interface{})
+	FatalfContext(ctx context.Context, format string, args ...interface{})
+	PanicfContext(ctx context.Context, format string, args ...interface{})
 }
@@ -1,6 +1,9 @@
 package glog
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 type Option interface {
 	apply(*Log)
@@ -43,3 +46,36 @@ func WithReportCaller(reportCaller bool) Option {
 		log.ReportCaller = reportCaller
 	})
 }
+
+// WithCallerSkip 在第一个非log调用函数之上额外跳过skip层调用，用于封装Log的场景
+func WithCallerSkip(skip int) Option {
+	return NewLogOption(func(log *Log) {
+		log.CallerSkip = skip
+	})
+}
+
+func WithContextExtractor(extractors ...ContextExtractor) Option {
+	return NewLogOption(func(log *Log) {
+		log.ContextExtractors = append(log.ContextExtractors, extractors...)
+	})
+}
+
+// WithDedup 合并窗口期内连续重复的日志(级别、消息和字段相同，不比较时间)
+func WithDedup(window time.Duration) Option {
+	return NewLogOption(func(log *Log) {
+		log.dedup = newDedup(window)
+	})
+}
+
+// WithSampling 每个周期内相同级别和消息的日志先输出first条，之后每thereafter条输出一条
+func WithSampling(tick time.Duration, first, thereafter int) Option {
+	return NewLogOption(func(log *Log) {
+		log.sampler = newSampler(tick, first, thereafter)
+	})
+}
+
+func WithHook(hooks ...Hook) Option {
+	return NewLogOption(func(log *Log) {
+		log.Hooks = append(log.Hooks, hooks...)
+	})
+}
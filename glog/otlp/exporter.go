@@ -0,0 +1,267 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yueluoa/infrastructure/ghttp"
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+const (
+	defaultBatchSize  = 512
+	defaultInterval   = 5 * time.Second
+	defaultQueueSize  = 2048
+	defaultMaxRetries = 3
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+	scopeName         = "github.com/yueluoa/infrastructure/glog"
+)
+
+var ErrExporterClosed = errors.New("otlp exporter closed")
+
+// 确保我们始终实现 glog.Hook
+var _ glog.Hook = (*Exporter)(nil)
+
+// Exporter 将日志批量转换成OTLP/HTTP JSON格式发送，通过glog.WithHook或Log.AddHook注册
+type Exporter struct {
+	endpoint       string
+	client         *ghttp.Client
+	resource       []keyValue
+	levels         []glog.Level
+	traceExtractor TraceExtractor
+	batchSize      int
+	interval       time.Duration
+	queueSize      int
+	maxRetries     int
+	backoff        time.Duration
+	maxBackoff     time.Duration
+
+	queue     chan logRecord
+	flushCh   chan chan error
+	closed    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	dropped   atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// NewExporter 创建导出器，endpoint为OTLP/HTTP日志接口地址，例如 http://localhost:4318/v1/logs
+func NewExporter(endpoint string, opts ...Option) *Exporter {
+	e := &Exporter{
+		endpoint:       endpoint,
+		client:         ghttp.NewClient(),
+		levels:         glog.AllLevels,
+		traceExtractor: traceFromContext,
+		batchSize:      defaultBatchSize,
+		interval:       defaultInterval,
+		queueSize:      defaultQueueSize,
+		maxRetries:     defaultMaxRetries,
+		backoff:        defaultBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		flushCh:        make(chan chan error),
+		closed:         make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(e)
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = defaultBatchSize
+	}
+	if e.interval <= 0 {
+		e.interval = defaultInterval
+	}
+	if e.queueSize <= 0 {
+		e.queueSize = defaultQueueSize
+	}
+	e.queue = make(chan logRecord, e.queueSize)
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+
+	go e.run()
+
+	return e
+}
+
+func (e *Exporter) Levels() []glog.Level {
+	return e.levels
+}
+
+// Fire 转换日志并放入发送队列，队列满或已经Shutdown时丢弃
+func (e *Exporter) Fire(entry *glog.Entry) error {
+	select {
+	case <-e.closed:
+		e.dropped.Add(1)
+		return nil
+	default:
+	}
+
+	var traceID, spanID string
+	if entry.Ctx != nil {
+		traceID, spanID = e.traceExtractor(entry.Ctx)
+	}
+	record := toRecord(entry, time.Now(), traceID, spanID)
+
+	select {
+	case e.queue <- record:
+	default:
+		e.dropped.Add(1)
+	}
+
+	return nil
+}
+
+// Flush 立即发送队列中的日志
+func (e *Exporter) Flush(ctx context.Context) error {
+	ack := make(chan error, 1)
+	select {
+	case e.flushCh <- ack:
+	case <-e.done:
+		return ErrExporterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-ack:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown 发送剩余的日志并停止导出，ctx结束时放弃未发送的日志
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.closeOnce.Do(func() {
+		close(e.closed)
+	})
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		e.cancel()
+		<-e.done
+		return ctx.Err()
+	}
+}
+
+// Dropped 返回因队列满或已经Shutdown而丢弃的日志数
+func (e *Exporter) Dropped() uint64 {
+	return e.dropped.Load()
+}
+
+// Failed 返回重试后仍发送失败的日志数
+func (e *Exporter) Failed() uint64 {
+	return e.failed.Load()
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	defer e.cancel()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]logRecord, 0, e.batchSize)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := e.export(batch)
+		if err != nil {
+			e.failed.Add(uint64(len(batch)))
+		}
+		batch = make([]logRecord, 0, e.batchSize)
+		return err
+	}
+	// 取出队列中剩余的日志并全部发送
+	drain := func() error {
+		var err error
+		for {
+			select {
+			case record := <-e.queue:
+				batch = append(batch, record)
+				if len(batch) >= e.batchSize {
+					if errSend := send(); err == nil {
+						err = errSend
+					}
+				}
+			default:
+				if errSend := send(); err == nil {
+					err = errSend
+				}
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case record := <-e.queue:
+			batch = append(batch, record)
+			if len(batch) >= e.batchSize {
+				_ = send()
+			}
+		case <-ticker.C:
+			_ = send()
+		case ack := <-e.flushCh:
+			ack <- drain()
+		case <-e.closed:
+			_ = drain()
+			return
+		}
+	}
+}
+
+func (e *Exporter) export(records []logRecord) error {
+	payload := exportRequest{
+		ResourceLogs: []resourceLogs{{
+			Resource: resource{Attributes: e.resource},
+			ScopeLogs: []scopeLogs{{
+				Scope:      scope{Name: scopeName},
+				LogRecords: records,
+			}},
+		}},
+	}
+
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		req, err := e.client.NewRequest(e.ctx, http.MethodPost, e.endpoint, payload)
+		if err != nil {
+			return err
+		}
+		err = e.client.SendRequest(req, nil)
+		if err == nil || attempt >= e.maxRetries || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-e.ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > e.maxBackoff {
+			backoff = e.maxBackoff
+		}
+	}
+}
+
+// 除408和429外的4xx错误重试也不会成功
+func retryable(err error) bool {
+	var httpErr *ghttp.HTTPError
+	if !errors.As(err, &httpErr) {
+		return true
+	}
+	switch httpErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return httpErr.StatusCode < 400 || httpErr.StatusCode >= 500
+}
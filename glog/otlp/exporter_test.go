@@ -0,0 +1,134 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+func TestExporter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []exportRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req exportRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		received = append(received, req)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	exporter := NewExporter(server.URL+"/v1/logs",
+		WithServiceName("order"),
+		WithBatch(10, time.Hour),
+		WithRetry(2, time.Millisecond, 10*time.Millisecond),
+	)
+	// glog.New只在第一次调用时生效，使用新的Log添加Hook
+	log, _, err := glog.NewFromConfig(&glog.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Out = io.Discard
+	log.AddHook(exporter)
+
+	ctx := ContextWithTrace(context.Background(), "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331")
+	log.WithField(glog.Field{Key: "order_id", Value: 42}).ErrorContext(ctx, "payment failed")
+	log.Info("plain")
+
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 || len(received) != 1 {
+		t.Fatalf("attempts = %d, received = %d", attempts, len(received))
+	}
+	rl := received[0].ResourceLogs[0]
+	if *rl.Resource.Attributes[0].Value.StringValue != "order" {
+		t.Fatalf("unexpected resource %+v", rl.Resource)
+	}
+	records := rl.ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("got %d records", len(records))
+	}
+	r := records[0]
+	if r.SeverityNumber != severityError || *r.Body.StringValue != "payment failed" ||
+		r.TraceID != "0af7651916cd43dd8448eb211c80319c" || r.SpanID != "b7ad6b7169203331" ||
+		r.Attributes[0].Key != "order_id" || *r.Attributes[0].Value.IntValue != "42" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if records[1].SeverityNumber != severityInfo || records[1].TraceID != "" {
+		t.Fatalf("unexpected record %+v", records[1])
+	}
+}
+
+func TestExporterPermanentError(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exporter := NewExporter(server.URL+"/v1/logs", WithRetry(3, time.Millisecond, time.Millisecond))
+	defer exporter.Shutdown(context.Background())
+
+	if err := exporter.Fire(glog.NewEntry(glog.New())); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Flush(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 || exporter.Failed() != 1 {
+		t.Fatalf("attempts = %d, failed = %d, want a single attempt for 400", attempts, exporter.Failed())
+	}
+}
+
+func TestExporterOptionsAndShutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	// 非法的配置使用默认值，不会panic
+	exporter := NewExporter(server.URL+"/v1/logs", WithBatch(-1, 0), WithQueueSize(-1))
+	if exporter.batchSize != defaultBatchSize || exporter.interval != defaultInterval || cap(exporter.queue) != defaultQueueSize {
+		t.Fatalf("batch = %d, interval = %v, queue = %d", exporter.batchSize, exporter.interval, cap(exporter.queue))
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shutdown后的日志直接丢弃
+	if err := exporter.Fire(glog.NewEntry(glog.New())); err != nil || exporter.Dropped() != 1 {
+		t.Fatalf("err = %v, dropped = %d", err, exporter.Dropped())
+	}
+}
@@ -0,0 +1,138 @@
+package otlp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+// OTLP/HTTP JSON 日志数据结构，参考 opentelemetry-proto logs/v1
+type exportRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// OTLP日志级别
+const (
+	severityDebug = 5
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+	severityFatal = 21
+	severityPanic = 24
+)
+
+func severityNumber(level glog.Level) int {
+	switch level {
+	case glog.DebugLevel:
+		return severityDebug
+	case glog.InfoLevel:
+		return severityInfo
+	case glog.WarnLevel:
+		return severityWarn
+	case glog.ErrorLevel:
+		return severityError
+	case glog.FatalLevel:
+		return severityFatal
+	case glog.PanicLevel:
+		return severityPanic
+	default:
+		return 0
+	}
+}
+
+func stringValue(s string) anyValue {
+	return anyValue{StringValue: &s}
+}
+
+func toAnyValue(v interface{}) anyValue {
+	switch val := v.(type) {
+	case string:
+		return stringValue(val)
+	case bool:
+		return anyValue{BoolValue: &val}
+	case int, int8, int16, int32, int64:
+		s := fmt.Sprint(val)
+		return anyValue{IntValue: &s}
+	case uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(val)
+		return anyValue{IntValue: &s}
+	case float32:
+		f := float64(val)
+		return anyValue{DoubleValue: &f}
+	case float64:
+		return anyValue{DoubleValue: &val}
+	case error:
+		return stringValue(val.Error())
+	default:
+		return stringValue(fmt.Sprint(val))
+	}
+}
+
+// observed为Exporter收到日志的时间
+func toRecord(entry *glog.Entry, observed time.Time, traceID, spanID string) logRecord {
+	record := logRecord{
+		TimeUnixNano:         strconv.FormatInt(entry.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(observed.UnixNano(), 10),
+		SeverityNumber:       severityNumber(entry.Level),
+		SeverityText:         strings.ToUpper(entry.Level.String()),
+		Body:                 stringValue(entry.Message),
+		TraceID:              traceID,
+		SpanID:               spanID,
+	}
+	for _, v := range entry.Data {
+		record.Attributes = append(record.Attributes, keyValue{Key: v.Key, Value: toAnyValue(v.Value)})
+	}
+	if entry.Caller != nil {
+		record.Attributes = append(record.Attributes,
+			keyValue{Key: "code.function", Value: stringValue(entry.Caller.Function)},
+			keyValue{Key: "code.filepath", Value: stringValue(entry.Caller.File)},
+			keyValue{Key: "code.lineno", Value: toAnyValue(entry.Caller.Line)},
+		)
+	}
+
+	return record
+}
@@ -0,0 +1,81 @@
+package otlp
+
+import (
+	"time"
+
+	"github.com/yueluoa/infrastructure/ghttp"
+	"github.com/yueluoa/infrastructure/glog"
+)
+
+type Option interface {
+	apply(*Exporter)
+}
+
+type ExporterOption struct {
+	f func(*Exporter)
+}
+
+func (eo *ExporterOption) apply(e *Exporter) {
+	eo.f(e)
+}
+
+func NewExporterOption(f func(*Exporter)) *ExporterOption {
+	return &ExporterOption{
+		f: f,
+	}
+}
+
+// WithClient 指定发送请求的ghttp.Client
+func WithClient(client *ghttp.Client) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.client = client
+	})
+}
+
+// WithServiceName 设置resource的service.name属性
+func WithServiceName(name string) Option {
+	return WithResourceAttribute("service.name", name)
+}
+
+func WithResourceAttribute(key string, value interface{}) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.resource = append(e.resource, keyValue{Key: key, Value: toAnyValue(value)})
+	})
+}
+
+// WithBatch 每批最多size条日志，最长等待interval发送一次，小于等于0时使用默认值512条和5秒
+func WithBatch(size int, interval time.Duration) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.batchSize = size
+		e.interval = interval
+	})
+}
+
+// WithQueueSize 等待发送的日志队列长度，队列满时丢弃新日志，小于等于0时使用默认值2048
+func WithQueueSize(size int) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.queueSize = size
+	})
+}
+
+// WithRetry 发送失败时最多重试maxRetries次，重试间隔从backoff开始指数增长，最大为maxBackoff
+func WithRetry(maxRetries int, backoff, maxBackoff time.Duration) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.maxRetries = maxRetries
+		e.backoff = backoff
+		e.maxBackoff = maxBackoff
+	})
+}
+
+// WithLevels 只导出指定级别的日志，默认导出全部级别
+func WithLevels(levels ...glog.Level) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.levels = levels
+	})
+}
+
+func WithTraceExtractor(extractor TraceExtractor) Option {
+	return NewExporterOption(func(e *Exporter) {
+		e.traceExtractor = extractor
+	})
+}
@@ -0,0 +1,28 @@
+package otlp
+
+import "context"
+
+type ctxTraceKey struct{}
+
+type traceInfo struct {
+	traceID string
+	spanID  string
+}
+
+// TraceExtractor 从context中提取十六进制的trace id和span id
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+// ContextWithTrace 在context中保存trace id和span id，默认的TraceExtractor会读取
+func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, ctxTraceKey{}, traceInfo{traceID: traceID, spanID: spanID})
+}
+
+func traceFromContext(ctx context.Context) (traceID, spanID string) {
+	if ctx == nil {
+		return "", ""
+	}
+	if info, ok := ctx.Value(ctxTraceKey{}).(traceInfo); ok {
+		return info.traceID, info.spanID
+	}
+	return "", ""
+}
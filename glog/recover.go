@@ -0,0 +1,180 @@
+package glog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
+
+const (
+	FieldKeyPanic     = "panic"
+	FieldKeyGoroutine = "goroutine"
+	FieldKeyStack     = "stack"
+)
+
+type RecoverOption interface {
+	apply(*recoverConfig)
+}
+
+type recoverConfig struct {
+	log     *Log
+	code    gerror.Code
+	rePanic bool
+	errp    *error
+	handler func(gerror.CodeError)
+}
+
+type recoverOption struct {
+	f func(*recoverConfig)
+}
+
+func (ro *recoverOption) apply(rc *recoverConfig) {
+	ro.f(rc)
+}
+
+func newRecoverOption(f func(*recoverConfig)) *recoverOption {
+	return &recoverOption{
+		f: f,
+	}
+}
+
+// WithRecoverLog 指定输出panic日志的Log，默认使用New返回的Log
+func WithRecoverLog(log *Log) RecoverOption {
+	return newRecoverOption(func(rc *recoverConfig) {
+		rc.log = log
+	})
+}
+
+// WithPanicCode 指定panic转换成的错误码，默认为gerror.CodePanic；错误仍然可以通过errors.Is匹配gerror.PanicError
+func WithPanicCode(code gerror.Code) RecoverOption {
+	return newRecoverOption(func(rc *recoverConfig) {
+		rc.code = code
+	})
+}
+
+// WithRePanic 输出日志后重新panic
+func WithRePanic() RecoverOption {
+	return newRecoverOption(func(rc *recoverConfig) {
+		rc.rePanic = true
+	})
+}
+
+// WithRecoverErr 将panic转换成的错误写入errp，用于 defer Recover 的函数返回错误
+func WithRecoverErr(errp *error) RecoverOption {
+	return newRecoverOption(func(rc *recoverConfig) {
+		rc.errp = errp
+	})
+}
+
+// WithRecoverHandler panic转换成错误后回调，例如HTTP handler返回统一的错误码
+func WithRecoverHandler(handler func(gerror.CodeError)) RecoverOption {
+	return newRecoverOption(func(rc *recoverConfig) {
+		rc.handler = handler
+	})
+}
+
+func newRecoverConfig(log *Log, opts []RecoverOption) *recoverConfig {
+	rc := &recoverConfig{
+		log:  log,
+		code: gerror.CodePanic,
+	}
+	for _, opt := range opts {
+		opt.apply(rc)
+	}
+	if rc.log == nil {
+		rc.log = New()
+	}
+
+	return rc
+}
+
+// Recover 恢复panic，以error级别输出panic值、协程id和完整堆栈，必须直接通过defer调用
+//
+//	defer glog.Recover(log, glog.WithRecoverErr(&err))
+func Recover(log *Log, opts ...RecoverOption) {
+	if r := recover(); r != nil {
+		handlePanic(r, newRecoverConfig(log, opts))
+	}
+}
+
+// Go 启动协程执行fn，fn中的panic会被恢复并输出日志
+func Go(fn func(), opts ...RecoverOption) {
+	rc := newRecoverConfig(nil, opts)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				handlePanic(r, rc)
+			}
+		}()
+		fn()
+	}()
+}
+
+// Try 执行fn，fn中的panic会被恢复并转换成gerror.CodeError返回
+func Try(fn func() error, opts ...RecoverOption) (err error) {
+	rc := newRecoverConfig(nil, opts)
+	defer func() {
+		if r := recover(); r != nil {
+			err = handlePanic(r, rc)
+		}
+	}()
+
+	return fn()
+}
+
+func handlePanic(r interface{}, rc *recoverConfig) gerror.CodeError {
+	stack := debug.Stack()
+
+	value := r
+	if entry, ok := r.(*Entry); ok {
+		// Log.Panic已经输出过日志，这里只记录消息
+		value = entry.Message
+	}
+
+	var codeErr gerror.CodeError
+	if err, ok := value.(error); ok {
+		codeErr = &panicError{gerror.WrapWithCode(err, rc.code, "panic")}
+	} else {
+		codeErr = &panicError{gerror.WithCode(rc.code, fmt.Sprintf("panic: %v", value))}
+	}
+
+	rc.log.WithFields([]Field{
+		{Key: FieldKeyPanic, Value: value},
+		{Key: FieldKeyGoroutine, Value: goroutineID(stack)},
+		{Key: FieldKeyStack, Value: string(stack)},
+	}).Error("recovered from panic")
+
+	if rc.errp != nil {
+		*rc.errp = codeErr
+	}
+	if rc.handler != nil {
+		rc.handler(codeErr)
+	}
+	if rc.rePanic {
+		panic(r)
+	}
+
+	return codeErr
+}
+
+// panicError panic转换成的错误，errors.Is可以匹配gerror.PanicError和panic的error值
+type panicError struct {
+	gerror.CodeError
+}
+
+func (e *panicError) Is(target error) bool {
+	return target == gerror.PanicError
+}
+
+// 从堆栈的第一行 "goroutine 12 [running]:" 中解析协程id
+func goroutineID(stack []byte) uint64 {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if i := bytes.IndexByte(stack, ' '); i > 0 {
+		id, _ := strconv.ParseUint(string(stack[:i]), 10, 64)
+		return id
+	}
+	return 0
+}
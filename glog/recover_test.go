@@ -0,0 +1,48 @@
+package glog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yueluoa/infrastructure/gerror"
+)
+
+func TestRecover(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newLog(WithOutput(buf))
+
+	cause := errors.New("boom")
+	run := func() (err error) {
+		defer Recover(log, WithRecoverErr(&err))
+		panic(cause)
+	}
+	err := run()
+	if !gerror.IsWithCode(gerror.CodePanic, err) || !errors.Is(err, cause) || !errors.Is(err, gerror.PanicError) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "[ERROR]") || !strings.Contains(out, "goroutine= ") || !strings.Contains(out, "TestRecover") {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	err = Try(func() error {
+		log.Panic("bad state")
+		return nil
+	}, WithRecoverLog(newLog(WithOutput(io.Discard))), WithPanicCode(gerror.CodeCommon))
+	if !gerror.IsWithCode(gerror.CodeCommon, err) || err.Error() != "panic: bad state" || !errors.Is(err, gerror.PanicError) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	done := make(chan gerror.CodeError)
+	Go(func() {
+		panic("in goroutine")
+	}, WithRecoverLog(log), WithRecoverHandler(func(err gerror.CodeError) {
+		done <- err
+	}))
+	if err := <-done; err.Error() != "panic: in goroutine" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package glog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultRingBufferSize = 100
+
+type ctxRingBufferKey struct{}
+
+// 保存低于当前级别的日志，出错时再输出
+type ringBuffer struct {
+	mu      sync.Mutex
+	entries []*Entry
+	start   int
+	size    int
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	if size <= 0 {
+		size = defaultRingBufferSize
+	}
+	return &ringBuffer{entries: make([]*Entry, 0, size), size: size}
+}
+
+// 缓冲区满时覆盖最早的日志
+func (rb *ringBuffer) add(entry *Entry) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if len(rb.entries) < rb.size {
+		rb.entries = append(rb.entries, entry)
+		return
+	}
+	rb.entries[rb.start] = entry
+	rb.start = (rb.start + 1) % rb.size
+}
+
+// 按写入顺序取出全部日志并清空缓冲区
+func (rb *ringBuffer) drain() []*Entry {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	entries := make([]*Entry, 0, len(rb.entries))
+	entries = append(entries, rb.entries[rb.start:]...)
+	entries = append(entries, rb.entries[:rb.start]...)
+	rb.entries = rb.entries[:0]
+	rb.start = 0
+
+	return entries
+}
+
+// ContextWithBuffer 为context创建大小为size的日志缓冲区，使用该context输出的低于当前级别的日志
+// 不会写入而是保存在缓冲区中，同一个context输出error及以上级别的日志时先输出缓冲区中的日志
+func ContextWithBuffer(ctx context.Context, size int) context.Context {
+	return context.WithValue(ctx, ctxRingBufferKey{}, newRingBuffer(size))
+}
+
+func ringBufferFromContext(ctx context.Context) *ringBuffer {
+	if ctx == nil {
+		return nil
+	}
+	rb, _ := ctx.Value(ctxRingBufferKey{}).(*ringBuffer)
+	return rb
+}
+
+// FlushBuffered 按顺序输出context缓冲区中的日志
+func FlushBuffered(ctx context.Context) {
+	rb := ringBufferFromContext(ctx)
+	if rb == nil {
+		return
+	}
+	for _, entry := range rb.drain() {
+		entry.write()
+	}
+}
+
+// 日志级别未开启时，context中有缓冲区也需要处理
+func (log *Log) shouldLog(ctx context.Context, level Level) bool {
+	return log.IsLevelEnabledContext(ctx, level) || ringBufferFromContext(ctx) != nil
+}
+
+// 复制当前entry保存到缓冲区
+func (entry *Entry) buffer(rb *ringBuffer, level Level, msg string) {
+	// 保留context，输出时Hook仍然可以从中获取trace等信息
+	buffered := &Entry{
+		Ctx:     entry.Ctx,
+		Log:     entry.Log,
+		Time:    entry.Time,
+		Level:   level,
+		Message: msg,
+	}
+	if buffered.Time.IsZero() {
+		buffered.Time = time.Now()
+	}
+
+	entry.Log.mu.Lock()
+	reportCaller := entry.Log.ReportCaller
+	callerSkip := entry.Log.CallerSkip
+	entry.Log.mu.Unlock()
+	if reportCaller {
+		buffered.Caller = entry.Log.getCaller(callerSkip + entry.callerSkip)
+	}
+
+	fields := entry.Log.contextFields(entry.Ctx)
+	buffered.Data = make([]Field, 0, len(entry.Data)+len(fields))
+	buffered.Data = append(buffered.Data, entry.Data...)
+	buffered.Data = append(buffered.Data, fields...)
+
+	rb.add(buffered)
+}
@@ -0,0 +1,70 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := newLog(WithOutput(buf))
+
+	ctx := ContextWithBuffer(context.Background(), 2)
+	log.DebugContext(ctx, "step 1")
+	log.WithContext(ctx).Debugf("step %d", 2)
+	log.DebugfContext(ctx, "step %d", 3)
+	log.InfoContext(ctx, "info")
+	if strings.Contains(buf.String(), "step") {
+		t.Fatalf("debug entries should be buffered: %q", buf.String())
+	}
+
+	log.ErrorContext(ctx, "failed")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 ||
+		!strings.HasSuffix(lines[0], "_msg= info") ||
+		!strings.HasSuffix(lines[1], "_msg= step 2") ||
+		!strings.HasSuffix(lines[2], "_msg= step 3") ||
+		!strings.HasSuffix(lines[3], "_msg= failed") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	// 已输出的日志不会重复输出
+	buf.Reset()
+	log.DebugContext(ctx, "step 4")
+	FlushBuffered(ctx)
+	FlushBuffered(ctx)
+	if strings.Count(buf.String(), "\n") != 1 || !strings.Contains(buf.String(), "[DEBUG]") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+type ctxHook struct {
+	traces []interface{}
+}
+
+func (h *ctxHook) Levels() []Level {
+	return AllLevels
+}
+
+func (h *ctxHook) Fire(entry *Entry) error {
+	var trace interface{}
+	if entry.Ctx != nil {
+		trace = entry.Ctx.Value(traceKey{})
+	}
+	h.traces = append(h.traces, trace)
+	return nil
+}
+
+func TestRingBufferContext(t *testing.T) {
+	hook := &ctxHook{}
+	log := newLog(WithOutput(&bytes.Buffer{}), WithHook(hook))
+
+	ctx := ContextWithBuffer(context.WithValue(context.Background(), traceKey{}, "trace-1"), 10)
+	log.DebugContext(ctx, "buffered")
+	log.ErrorContext(ctx, "failed")
+	if len(hook.traces) != 2 || hook.traces[0] != "trace-1" || hook.traces[1] != "trace-1" {
+		t.Fatalf("hook traces = %v", hook.traces)
+	}
+}
@@ -0,0 +1,49 @@
+package glog
+
+import (
+	"time"
+)
+
+// 每个周期内相同级别和消息的日志先输出first条，之后每thereafter条输出一条
+type sampler struct {
+	tick       time.Duration
+	first      int
+	thereafter int
+	counts     map[string]int
+	reset      time.Time
+}
+
+func newSampler(tick time.Duration, first, thereafter int) *sampler {
+	return &sampler{
+		tick:       tick,
+		first:      first,
+		thereafter: thereafter,
+		counts:     make(map[string]int),
+	}
+}
+
+// 调用时需要持有log.mu，返回true表示当前日志被采样丢弃
+func (s *sampler) drop(entry *Entry) bool {
+	// fatal和panic日志始终输出
+	if entry.Level <= FatalLevel {
+		return false
+	}
+
+	now := time.Now()
+	if now.Sub(s.reset) >= s.tick {
+		s.counts = make(map[string]int, len(s.counts))
+		s.reset = now
+	}
+
+	key := entry.Level.String() + "\x00" + entry.Message
+	n := s.counts[key] + 1
+	s.counts[key] = n
+	if n <= s.first {
+		return false
+	}
+	if s.thereafter > 0 && (n-s.first)%s.thereafter == 0 {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package glog
+
+import (
+	"io"
+)
+
+// LevelWriter 可以按日志级别写入的输出，Log.Out实现该接口时会调用WriteLevel
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (n int, err error)
+}
+
+// Sink 日志输出目标，只写入Level及以上级别的日志
+type Sink struct {
+	Writer io.Writer
+	Level  Level
+}
+
+// 确保我们始终实现 LevelWriter
+var _ LevelWriter = (*MultiSink)(nil)
+
+// MultiSink 将日志写入多个输出目标
+type MultiSink struct {
+	sinks []Sink
+}
+
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+// Write 写入全部输出目标
+func (ms *MultiSink) Write(p []byte) (n int, err error) {
+	return ms.WriteLevel(PanicLevel, p)
+}
+
+func (ms *MultiSink) WriteLevel(level Level, p []byte) (n int, err error) {
+	for _, sink := range ms.sinks {
+		if sink.Level < level {
+			continue
+		}
+		if _, errWrite := writeLevel(sink.Writer, level, p); err == nil && errWrite != nil {
+			err = errWrite
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close 关闭实现了io.Closer的输出目标
+func (ms *MultiSink) Close() error {
+	var err error
+	for _, sink := range ms.sinks {
+		if c, ok := sink.Writer.(io.Closer); ok {
+			if errClose := c.Close(); err == nil && errClose != nil {
+				err = errClose
+			}
+		}
+	}
+	return err
+}
+
+func writeLevel(w io.Writer, level Level, p []byte) (int, error) {
+	if lw, ok := w.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.Write(p)
+}
@@ -0,0 +1,153 @@
+package glog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type logStats struct {
+	entries     [DebugLevel + 1]atomic.Uint64
+	dropped     atomic.Uint64
+	sampled     atomic.Uint64
+	bytes       atomic.Uint64
+	formatNanos atomic.Uint64
+	writeNanos  atomic.Uint64
+}
+
+func (s *logStats) addEntry(level Level) {
+	if level <= DebugLevel {
+		s.entries[level].Add(1)
+	}
+}
+
+// LogStats 日志统计快照
+type LogStats struct {
+	Entries        map[Level]uint64 // 每个级别输出的日志条数
+	Dropped        uint64           // 格式化或写入失败而丢弃的日志条数
+	Sampled        uint64           // 被采样丢弃的日志条数
+	Bytes          uint64           // 写入的字节数
+	FormatDuration time.Duration    // 格式化累计耗时
+	WriteDuration  time.Duration    // 写入累计耗时
+}
+
+func (log *Log) Stats() LogStats {
+	stats := LogStats{
+		Entries:        make(map[Level]uint64, len(AllLevels)),
+		Dropped:        log.stats.dropped.Load(),
+		Sampled:        log.stats.sampled.Load(),
+		Bytes:          log.stats.bytes.Load(),
+		FormatDuration: time.Duration(log.stats.formatNanos.Load()),
+		WriteDuration:  time.Duration(log.stats.writeNanos.Load()),
+	}
+	for _, level := range AllLevels {
+		stats.Entries[level] = log.stats.entries[level].Load()
+	}
+
+	return stats
+}
+
+type fileStats struct {
+	bytes          atomic.Uint64
+	writeErrors    atomic.Uint64
+	rotations      atomic.Uint64
+	compressions   atomic.Uint64
+	compressErrors atomic.Uint64
+	millRuns       atomic.Uint64
+	millNanos      atomic.Uint64
+}
+
+// FileStats 日志文件统计快照
+type FileStats struct {
+	Bytes          uint64        // 写入的字节数
+	WriteErrors    uint64        // 写入失败次数
+	Rotations      uint64        // 轮转次数
+	Compressions   uint64        // 压缩的文件数
+	CompressErrors uint64        // 压缩失败的文件数
+	MillRuns       uint64        // 清理和压缩旧文件的执行次数
+	MillDuration   time.Duration // 清理和压缩旧文件的累计耗时
+}
+
+func (lf *LogFile) Stats() FileStats {
+	return FileStats{
+		Bytes:          lf.stats.bytes.Load(),
+		WriteErrors:    lf.stats.writeErrors.Load(),
+		Rotations:      lf.stats.rotations.Load(),
+		Compressions:   lf.stats.compressions.Load(),
+		CompressErrors: lf.stats.compressErrors.Load(),
+		MillRuns:       lf.stats.millRuns.Load(),
+		MillDuration:   time.Duration(lf.stats.millNanos.Load()),
+	}
+}
+
+// MetricsHandler 以Prometheus文本格式输出日志统计
+func MetricsHandler(log *Log, files ...*LogFile) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := &bytes.Buffer{}
+		writeLogMetrics(b, log.Stats())
+		writeFileMetrics(b, files)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(b.Bytes())
+	})
+}
+
+// Prometheus文本格式的标签值只需要转义反斜杠、双引号和换行符，其他字符(包括非ASCII字符)原样输出
+var labelValueEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func writeMetricHeader(b *bytes.Buffer, name, help, typ string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeLogMetrics(b *bytes.Buffer, stats LogStats) {
+	writeMetricHeader(b, "glog_entries_total", "Number of log entries by level.", "counter")
+	for _, level := range AllLevels {
+		fmt.Fprintf(b, "glog_entries_total{level=\"%s\"} %d\n", escapeLabelValue(level.String()), stats.Entries[level])
+	}
+	writeMetricHeader(b, "glog_dropped_total", "Number of log entries dropped by format or write errors.", "counter")
+	fmt.Fprintf(b, "glog_dropped_total %d\n", stats.Dropped)
+	writeMetricHeader(b, "glog_sampled_total", "Number of log entries dropped by sampling.", "counter")
+	fmt.Fprintf(b, "glog_sampled_total %d\n", stats.Sampled)
+	writeMetricHeader(b, "glog_written_bytes_total", "Number of bytes written to the output.", "counter")
+	fmt.Fprintf(b, "glog_written_bytes_total %d\n", stats.Bytes)
+	writeMetricHeader(b, "glog_format_seconds_total", "Total time spent formatting log entries.", "counter")
+	fmt.Fprintf(b, "glog_format_seconds_total %g\n", stats.FormatDuration.Seconds())
+	writeMetricHeader(b, "glog_write_seconds_total", "Total time spent writing log entries.", "counter")
+	fmt.Fprintf(b, "glog_write_seconds_total %g\n", stats.WriteDuration.Seconds())
+}
+
+func writeFileMetrics(b *bytes.Buffer, files []*LogFile) {
+	if len(files) == 0 {
+		return
+	}
+	stats := make([]FileStats, len(files))
+	for i, lf := range files {
+		stats[i] = lf.Stats()
+	}
+
+	metrics := []struct {
+		name, help string
+		value      func(FileStats) interface{}
+	}{
+		{"glog_file_written_bytes_total", "Number of bytes written to the log file.", func(s FileStats) interface{} { return s.Bytes }},
+		{"glog_file_write_errors_total", "Number of failed log file writes.", func(s FileStats) interface{} { return s.WriteErrors }},
+		{"glog_file_rotations_total", "Number of log file rotations.", func(s FileStats) interface{} { return s.Rotations }},
+		{"glog_file_compressions_total", "Number of compressed backup files.", func(s FileStats) interface{} { return s.Compressions }},
+		{"glog_file_compress_errors_total", "Number of failed backup file compressions.", func(s FileStats) interface{} { return s.CompressErrors }},
+		{"glog_file_mill_runs_total", "Number of backup cleanup and compression runs.", func(s FileStats) interface{} { return s.MillRuns }},
+		{"glog_file_mill_seconds_total", "Total time spent cleaning up and compressing backups.", func(s FileStats) interface{} { return s.MillDuration.Seconds() }},
+	}
+	for _, m := range metrics {
+		writeMetricHeader(b, m.name, m.help, "counter")
+		for i, lf := range files {
+			fmt.Fprintf(b, "%s{file=\"%s\"} %v\n", m.name, escapeLabelValue(lf.filename()), m.value(stats[i]))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package glog
+
+import (
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	lf := &LogFile{Filename: filepath.Join(t.TempDir(), "app.log")}
+	log := newLog(WithOutput(lf))
+	log.Info("a")
+	log.Info("b")
+	log.Error("c")
+	log.Debug("disabled")
+	if err := lf.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := log.Stats()
+	if stats.Entries[InfoLevel] != 2 || stats.Entries[ErrorLevel] != 1 || stats.Entries[DebugLevel] != 0 {
+		t.Fatalf("unexpected entries %v", stats.Entries)
+	}
+	if fs := lf.Stats(); fs.Bytes != stats.Bytes || fs.Rotations != 1 {
+		t.Fatalf("unexpected file stats %+v", fs)
+	}
+
+	rec := httptest.NewRecorder()
+	MetricsHandler(log, lf).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`glog_entries_total{level="info"} 2`,
+		`glog_file_rotations_total{file="` + lf.Filename + `"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	got := escapeLabelValue("日志\\a\"b\nc\td")
+	if want := "日志\\\\a\\\"b\\nc\td"; got != want {
+		t.Fatalf("escape = %q, want %q", got, want)
+	}
+}
@@ -1,10 +1,8 @@
 package glog
 
-import "sync"
-
 const (
 	maximumCallerDepth int = 25
-	knownLogFrames     int = 4
+	minimumCallerDepth int = 2 // 跳过runtime.Callers和getCaller
 )
 
 var exitCode = 1
@@ -13,19 +11,7 @@ var ErrorKey = "error"
 
 type exitFunc func(int)
 
-var (
-	//
-	logPackage string
-	// 跟踪报告调用方法时在调用堆栈中的位置
-	minimumCallerDepth int
-	callerInitOnce     sync.Once
-)
-
 type Field struct {
 	Key   string
 	Value interface{}
 }
-
-func init() {
-	minimumCallerDepth = 1
-}
@@ -6,4 +6,5 @@ require (
 	github.com/lionsoul2014/ip2region/binding/golang v0.0.0-20231008093706-3ef87ff7272b
 	golang.org/x/crypto v0.9.0
 	golang.org/x/exp v0.0.0-20240808152545-0cdaa3abc0fa
+	gopkg.in/yaml.v3 v3.0.1
 )